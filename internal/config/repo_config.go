@@ -25,6 +25,21 @@ type RepositoryConfig struct {
 		AllowDestructiveUsers        bool `yaml:"users"`
 		AllowDestructiveRulesets     bool `yaml:"rulesets"`
 	} `yaml:"destructive_operations"`
+	Validation struct {
+		ErrorOnWarnings                 []string          `yaml:"error_on_warnings"`                  // warning codes reported as errors
+		RepositoryMaxTeamDepth          int               `yaml:"repository_max_team_depth"`          // 0: unlimited
+		RepositoryNamePattern           string            `yaml:"repository_name_pattern"`            // regular expression the repositories names must match
+		RepositoryNameFromFilename      string            `yaml:"repository_name_from_filename"`      // regular expression capturing the repository name in its filename
+		RepositoryReservedNames         []string          `yaml:"repository_reserved_names"`          // replace the default reserved names
+		RepositoryPropertyKeys          []string          `yaml:"repository_property_keys"`           // custom properties defined in the organization
+		RulesetAvailableFeatures        []string          `yaml:"ruleset_available_features"`         // rule types available with the Github plan
+		RulesetMaxRules                 int               `yaml:"ruleset_max_rules"`                  // 0: default maximum
+		AllowedStatusChecksIntegrations []int             `yaml:"allowed_status_checks_integrations"` // Github App ids allowed to provide status checks
+		OwnerRequiredReviewer           bool              `yaml:"owner_required_reviewer"`
+		AppVisibilityScopes             map[string]string `yaml:"app_visibility_scopes"` // Github App name -> "internal" for internal-only apps
+		Variables                       map[string]string `yaml:"variables"`             // ${NAME} placeholders substituted in the repositories spec
+		StrictVariables                 bool              `yaml:"strict_variables"`      // an unresolved placeholder is an error
+	} `yaml:"validation"`
 }
 
 // set default values
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/entity"
@@ -350,8 +351,13 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 
 type GithubRepoComparable struct {
 	BoolProperties      map[string]bool
+	StringProperties    map[string]string // only the properties managed by Goliac
+	DefaultBranch       string            // empty: not managed by Goliac
+	Topics              []string          // empty: not managed by Goliac
 	Writers             []string
 	Readers             []string
+	Triagers            []string
+	Maintainers         []string
 	ExternalUserReaders []string // githubids
 	ExternalUserWriters []string // githubids
 	InternalUsers       []string // githubids
@@ -394,8 +400,13 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 	for k, v := range ghRepos {
 		repo := &GithubRepoComparable{
 			BoolProperties:      map[string]bool{},
+			StringProperties:    map[string]string{},
+			DefaultBranch:       v.DefaultBranch,
+			Topics:              v.Topics,
 			Writers:             []string{},
 			Readers:             []string{},
+			Triagers:            []string{},
+			Maintainers:         []string{},
 			ExternalUserReaders: []string{},
 			ExternalUserWriters: []string{},
 			InternalUsers:       []string{},
@@ -404,6 +415,9 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 		for pk, pv := range v.BoolProperties {
 			repo.BoolProperties[pk] = pv
 		}
+		for pk, pv := range v.StringProperties {
+			repo.StringProperties[pk] = pv
+		}
 
 		for cGithubid, cPermission := range v.ExternalUsers {
 			if cPermission == "WRITE" {
@@ -425,9 +439,14 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 	for t, repos := range remote.TeamRepositories() {
 		for r, p := range repos {
 			if rr, ok := rRepos[r]; ok {
-				if p.Permission == "ADMIN" || p.Permission == "WRITE" {
+				switch p.Permission {
+				case "ADMIN", "WRITE":
 					rr.Writers = append(rr.Writers, t)
-				} else {
+				case "MAINTAIN":
+					rr.Maintainers = append(rr.Maintainers, t)
+				case "TRIAGE":
+					rr.Triagers = append(rr.Triagers, t)
+				default:
 					rr.Readers = append(rr.Readers, t)
 				}
 			}
@@ -458,6 +477,14 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 		for _, r := range lRepo.Spec.Readers {
 			readers = append(readers, slug.Make(r))
 		}
+		triagers := make([]string, 0)
+		for _, t := range lRepo.Spec.TriageTeams {
+			triagers = append(triagers, slug.Make(t))
+		}
+		maintainers := make([]string, 0)
+		for _, m := range lRepo.Spec.MaintainTeams {
+			maintainers = append(maintainers, slug.Make(m))
+		}
 
 		// special case for the Goliac "teams" repo
 		if reponame == teamsreponame {
@@ -481,6 +508,10 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 
 		eWriters := make([]string, 0)
 		for _, w := range lRepo.Spec.ExternalUserWriters {
+			// time-boxed access: the expired grants are revoked
+			if lRepo.ExternalUserWriterExpired(w, time.Now()) {
+				continue
+			}
 			if user, ok := local.ExternalUsers()[w]; ok {
 				eWriters = append(eWriters, user.Spec.GithubID)
 			}
@@ -490,6 +521,7 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 		for _, rs := range lRepo.Spec.Rulesets {
 			ruleset := GithubRuleSet{
 				Name:        rs.Name,
+				Target:      rs.Target,
 				Enforcement: rs.Enforcement,
 				BypassApps:  map[string]string{},
 				OnInclude:   rs.Conditions.Include,
@@ -505,16 +537,47 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			rulesets[rs.Name] = &ruleset
 		}
 
+		boolProperties := map[string]bool{
+			"private":                !lRepo.Spec.IsPublic,
+			"archived":               lRepo.Archived,
+			"allow_auto_merge":       lRepo.Spec.AllowAutoMerge,
+			"delete_branch_on_merge": lRepo.Spec.DeleteBranchOnMerge,
+			"allow_update_branch":    lRepo.Spec.AllowUpdateBranch,
+		}
+		// the optional properties are only managed when they are defined
+		for propertyName, propertyValue := range map[string]*bool{
+			"allow_merge_commit":          lRepo.Spec.AllowMergeCommit,
+			"allow_squash_merge":          lRepo.Spec.AllowSquashMerge,
+			"allow_rebase_merge":          lRepo.Spec.AllowRebaseMerge,
+			"web_commit_signoff_required": lRepo.Spec.WebCommitSignoffRequired,
+			"dependabot_alerts":           lRepo.Spec.DependabotAlerts,
+			"has_discussions":             lRepo.Spec.HasDiscussions,
+			"has_issues":                  lRepo.Spec.HasIssues,
+		} {
+			if propertyValue != nil {
+				boolProperties[propertyName] = *propertyValue
+			}
+		}
+
+		stringProperties := map[string]string{}
+		for propertyName, propertyValue := range map[string]string{
+			"merge_commit_title":   lRepo.Spec.MergeCommitTitle,
+			"merge_commit_message": lRepo.Spec.MergeCommitMessage,
+		} {
+			if propertyValue != "" {
+				stringProperties[propertyName] = propertyValue
+			}
+		}
+
 		lRepos[utils.GithubAnsiString(reponame)] = &GithubRepoComparable{
-			BoolProperties: map[string]bool{
-				"private":                !lRepo.Spec.IsPublic,
-				"archived":               lRepo.Archived,
-				"allow_auto_merge":       lRepo.Spec.AllowAutoMerge,
-				"delete_branch_on_merge": lRepo.Spec.DeleteBranchOnMerge,
-				"allow_update_branch":    lRepo.Spec.AllowUpdateBranch,
-			},
+			BoolProperties:      boolProperties,
+			StringProperties:    stringProperties,
+			DefaultBranch:       lRepo.Spec.DefaultBranch,
+			Topics:              lRepo.Spec.Topics,
 			Readers:             readers,
 			Writers:             writers,
+			Triagers:            triagers,
+			Maintainers:         maintainers,
 			ExternalUserReaders: eReaders,
 			ExternalUserWriters: eWriters,
 			InternalUsers:       []string{},
@@ -552,6 +615,22 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			}
 		}
 
+		for lk, lv := range lRepo.StringProperties {
+			if rRepo.StringProperties[lk] != lv {
+				return false
+			}
+		}
+
+		if lRepo.DefaultBranch != "" && lRepo.DefaultBranch != rRepo.DefaultBranch {
+			return false
+		}
+
+		if len(lRepo.Topics) > 0 {
+			if res, _, _ := entity.StringArrayEquivalent(lRepo.Topics, rRepo.Topics); !res {
+				return false
+			}
+		}
+
 		if res, _, _ := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
 			return false
 		}
@@ -560,6 +639,14 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			return false
 		}
 
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.Triagers, rRepo.Triagers); !res {
+			return false
+		}
+
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.Maintainers, rRepo.Maintainers); !res {
+			return false
+		}
+
 		if len(rRepo.InternalUsers) != 0 {
 			return false
 		}
@@ -583,6 +670,23 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			}
 		}
 
+		for lk, lv := range lRepo.StringProperties {
+			if rRepo.StringProperties[lk] != lv {
+				r.UpdateRepositoryUpdateStringProperty(ctx, dryrun, remote, reponame, lk, lv)
+			}
+		}
+
+		// the default branch must exist: it is only set on existing repositories
+		if lRepo.DefaultBranch != "" && lRepo.DefaultBranch != rRepo.DefaultBranch {
+			r.UpdateRepositoryUpdateDefaultBranch(ctx, dryrun, remote, reponame, lRepo.DefaultBranch)
+		}
+
+		if len(lRepo.Topics) > 0 {
+			if res, _, _ := entity.StringArrayEquivalent(lRepo.Topics, rRepo.Topics); !res {
+				r.UpdateRepositoryUpdateTopics(ctx, dryrun, remote, reponame, lRepo.Topics)
+			}
+		}
+
 		if res, readToRemove, readToAdd := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
 			for _, teamSlug := range readToAdd {
 				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "pull")
@@ -592,6 +696,15 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			}
 		}
 
+		if res, triageToRemove, triageToAdd := entity.StringArrayEquivalent(lRepo.Triagers, rRepo.Triagers); !res {
+			for _, teamSlug := range triageToAdd {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "triage")
+			}
+			for _, teamSlug := range triageToRemove {
+				r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+			}
+		}
+
 		if res, writeToRemove, writeToAdd := entity.StringArrayEquivalent(lRepo.Writers, rRepo.Writers); !res {
 			for _, teamSlug := range writeToAdd {
 				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "push")
@@ -601,6 +714,15 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			}
 		}
 
+		if res, maintainToRemove, maintainToAdd := entity.StringArrayEquivalent(lRepo.Maintainers, rRepo.Maintainers); !res {
+			for _, teamSlug := range maintainToAdd {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "maintain")
+			}
+			for _, teamSlug := range maintainToRemove {
+				r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+			}
+		}
+
 		// internal users
 		for _, internalUser := range rRepo.InternalUsers {
 			r.UpdateRepositoryRemoveInternalUser(ctx, dryrun, remote, reponame, internalUser)
@@ -661,6 +783,19 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 			onChanged(reponame, aRepo, rRepo)
 		} else {
 			r.CreateRepository(ctx, dryrun, remote, reponame, reponame, lRepo.Writers, lRepo.Readers, lRepo.BoolProperties)
+			// the string properties, the topics and the triage and maintain permissions are set once the repository exists
+			for lk, lv := range lRepo.StringProperties {
+				r.UpdateRepositoryUpdateStringProperty(ctx, dryrun, remote, reponame, lk, lv)
+			}
+			if len(lRepo.Topics) > 0 {
+				r.UpdateRepositoryUpdateTopics(ctx, dryrun, remote, reponame, lRepo.Topics)
+			}
+			for _, teamSlug := range lRepo.Triagers {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "triage")
+			}
+			for _, teamSlug := range lRepo.Maintainers {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "maintain")
+			}
 		}
 	}
 
@@ -685,6 +820,16 @@ func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context,
 	return nil
 }
 
+/*
+rulesetTarget returns the ruleset target, "branch" if not set
+*/
+func rulesetTarget(rs *GithubRuleSet) string {
+	if rs.Target == "" {
+		return "branch"
+	}
+	return rs.Target
+}
+
 /*
 used to compare org rulesets but also repo rulesets
 */
@@ -692,6 +837,9 @@ func compareRulesets(rulesetname string, lrs *GithubRuleSet, rrs *GithubRuleSet)
 	if lrs.Enforcement != rrs.Enforcement {
 		return false
 	}
+	if rulesetTarget(lrs) != rulesetTarget(rrs) {
+		return false
+	}
 	if len(lrs.BypassApps) != len(rrs.BypassApps) {
 		return false
 	}
@@ -738,6 +886,7 @@ func (r *GoliacReconciliatorImpl) reconciliateRulesets(ctx context.Context, loca
 
 		grs := GithubRuleSet{
 			Name:        rs.Name,
+			Target:      rs.Spec.Target,
 			Enforcement: rs.Spec.Enforcement,
 			BypassApps:  map[string]string{},
 			OnInclude:   rs.Spec.Conditions.Include,
@@ -750,12 +899,12 @@ func (r *GoliacReconciliatorImpl) reconciliateRulesets(ctx context.Context, loca
 		for _, r := range rs.Spec.Rules {
 			grs.Rules[r.Ruletype] = r.Parameters
 		}
-		for reponame := range repositories {
-			if match.Match([]byte(reponame)) {
+		for _, reponame := range entity.SortedRepositoryNames(repositories) {
+			if match.Match([]byte(reponame)) && rs.AppliesTo(repositories[reponame]) {
 				grs.Repositories = append(grs.Repositories, reponame)
 			}
 		}
-		if match.Match([]byte(teamsreponame)) {
+		if match.Match([]byte(teamsreponame)) && rs.AppliesTo(nil) {
 			grs.Repositories = append(grs.Repositories, teamsreponame)
 		}
 		lgrs[rs.Name] = &grs
@@ -921,6 +1070,27 @@ func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateBoolProperty(ctx context
 		r.executor.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, reponame, propertyName, propertyValue)
 	}
 }
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyName string, propertyValue string) {
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "command": "update_repository_update_string_property"}).Infof("repositoryname: %s %s:%s", reponame, propertyName, propertyValue)
+	remote.UpdateRepositoryUpdateStringProperty(reponame, propertyName, propertyValue)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryUpdateStringProperty(ctx, dryrun, reponame, propertyName, propertyValue)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, topics []string) {
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "command": "update_repository_update_topics"}).Infof("repositoryname: %s topics:%s", reponame, strings.Join(topics, ","))
+	remote.UpdateRepositoryUpdateTopics(reponame, topics)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryUpdateTopics(ctx, dryrun, reponame, topics)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, branch string) {
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "command": "update_repository_update_default_branch"}).Infof("repositoryname: %s default_branch:%s", reponame, branch)
+	remote.UpdateRepositoryUpdateDefaultBranch(reponame, branch)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryUpdateDefaultBranch(ctx, dryrun, reponame, branch)
+	}
+}
 func (r *GoliacReconciliatorImpl) AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
 	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "command": "add_ruleset"}).Infof("ruleset: %s (id: %d) enforcement: %s", ruleset.Name, ruleset.Id, ruleset.Enforcement)
 	if r.executor != nil {
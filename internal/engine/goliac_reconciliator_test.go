@@ -140,20 +140,24 @@ type ReconciliatorListenerRecorder struct {
 	TeamParentUpdated map[string]*int
 	TeamDeleted       map[string]bool
 
-	RepositoryCreated              map[string]bool
-	RepositoryTeamAdded            map[string][]string
-	RepositoryTeamUpdated          map[string][]string
-	RepositoryTeamRemoved          map[string][]string
-	RepositoriesDeleted            map[string]bool
-	RepositoriesRenamed            map[string]bool
-	RepositoriesUpdatePrivate      map[string]bool
-	RepositoriesUpdateArchived     map[string]bool
-	RepositoriesSetExternalUser    map[string]string
-	RepositoriesRemoveExternalUser map[string]bool
-	RepositoriesRemoveInternalUser map[string]bool
-	RepositoryRuleSetCreated       map[string]map[string]*GithubRuleSet
-	RepositoryRuleSetUpdated       map[string]map[string]*GithubRuleSet
-	RepositoryRuleSetDeleted       map[string][]int
+	RepositoryCreated               map[string]bool
+	RepositoryTeamAdded             map[string][]string
+	RepositoryTeamUpdated           map[string][]string
+	RepositoryTeamRemoved           map[string][]string
+	RepositoriesDeleted             map[string]bool
+	RepositoriesRenamed             map[string]bool
+	RepositoriesUpdatePrivate       map[string]bool
+	RepositoriesUpdateArchived      map[string]bool
+	RepositoryDefaultBranchUpdated  map[string]string
+	RepositoryBoolPropertyUpdated   map[string]map[string]bool
+	RepositoryStringPropertyUpdated map[string]map[string]string
+	RepositoryTopicsUpdated         map[string][]string
+	RepositoriesSetExternalUser     map[string]string
+	RepositoriesRemoveExternalUser  map[string]bool
+	RepositoriesRemoveInternalUser  map[string]bool
+	RepositoryRuleSetCreated        map[string]map[string]*GithubRuleSet
+	RepositoryRuleSetUpdated        map[string]map[string]*GithubRuleSet
+	RepositoryRuleSetDeleted        map[string][]int
 
 	RuleSetCreated map[string]*GithubRuleSet
 	RuleSetUpdated map[string]*GithubRuleSet
@@ -162,31 +166,35 @@ type ReconciliatorListenerRecorder struct {
 
 func NewReconciliatorListenerRecorder() *ReconciliatorListenerRecorder {
 	r := ReconciliatorListenerRecorder{
-		UsersCreated:                   make(map[string]string),
-		UsersRemoved:                   make(map[string]string),
-		TeamsCreated:                   make(map[string][]string),
-		TeamMemberAdded:                make(map[string][]string),
-		TeamMemberRemoved:              make(map[string][]string),
-		TeamMemberUpdated:              make(map[string][]string),
-		TeamParentUpdated:              make(map[string]*int),
-		TeamDeleted:                    make(map[string]bool),
-		RepositoryCreated:              make(map[string]bool),
-		RepositoryTeamAdded:            make(map[string][]string),
-		RepositoryTeamUpdated:          make(map[string][]string),
-		RepositoryTeamRemoved:          make(map[string][]string),
-		RepositoriesDeleted:            make(map[string]bool),
-		RepositoriesRenamed:            make(map[string]bool),
-		RepositoriesUpdatePrivate:      make(map[string]bool),
-		RepositoriesUpdateArchived:     make(map[string]bool),
-		RepositoriesSetExternalUser:    make(map[string]string),
-		RepositoriesRemoveExternalUser: make(map[string]bool),
-		RepositoriesRemoveInternalUser: make(map[string]bool),
-		RepositoryRuleSetCreated:       make(map[string]map[string]*GithubRuleSet),
-		RepositoryRuleSetUpdated:       make(map[string]map[string]*GithubRuleSet),
-		RepositoryRuleSetDeleted:       make(map[string][]int, 0),
-		RuleSetCreated:                 make(map[string]*GithubRuleSet),
-		RuleSetUpdated:                 make(map[string]*GithubRuleSet),
-		RuleSetDeleted:                 make([]int, 0),
+		UsersCreated:                    make(map[string]string),
+		UsersRemoved:                    make(map[string]string),
+		TeamsCreated:                    make(map[string][]string),
+		TeamMemberAdded:                 make(map[string][]string),
+		TeamMemberRemoved:               make(map[string][]string),
+		TeamMemberUpdated:               make(map[string][]string),
+		TeamParentUpdated:               make(map[string]*int),
+		TeamDeleted:                     make(map[string]bool),
+		RepositoryCreated:               make(map[string]bool),
+		RepositoryTeamAdded:             make(map[string][]string),
+		RepositoryTeamUpdated:           make(map[string][]string),
+		RepositoryTeamRemoved:           make(map[string][]string),
+		RepositoriesDeleted:             make(map[string]bool),
+		RepositoriesRenamed:             make(map[string]bool),
+		RepositoriesUpdatePrivate:       make(map[string]bool),
+		RepositoriesUpdateArchived:      make(map[string]bool),
+		RepositoryDefaultBranchUpdated:  make(map[string]string),
+		RepositoryBoolPropertyUpdated:   make(map[string]map[string]bool),
+		RepositoryStringPropertyUpdated: make(map[string]map[string]string),
+		RepositoryTopicsUpdated:         make(map[string][]string),
+		RepositoriesSetExternalUser:     make(map[string]string),
+		RepositoriesRemoveExternalUser:  make(map[string]bool),
+		RepositoriesRemoveInternalUser:  make(map[string]bool),
+		RepositoryRuleSetCreated:        make(map[string]map[string]*GithubRuleSet),
+		RepositoryRuleSetUpdated:        make(map[string]map[string]*GithubRuleSet),
+		RepositoryRuleSetDeleted:        make(map[string][]int, 0),
+		RuleSetCreated:                  make(map[string]*GithubRuleSet),
+		RuleSetUpdated:                  make(map[string]*GithubRuleSet),
+		RuleSetDeleted:                  make([]int, 0),
 	}
 	return &r
 }
@@ -234,6 +242,22 @@ func (r *ReconciliatorListenerRecorder) RenameRepository(ctx context.Context, dr
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
 	r.RepositoriesUpdatePrivate[reponame] = true
+	if r.RepositoryBoolPropertyUpdated[reponame] == nil {
+		r.RepositoryBoolPropertyUpdated[reponame] = make(map[string]bool)
+	}
+	r.RepositoryBoolPropertyUpdated[reponame][propertyName] = propertyValue
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	if r.RepositoryStringPropertyUpdated[reponame] == nil {
+		r.RepositoryStringPropertyUpdated[reponame] = make(map[string]string)
+	}
+	r.RepositoryStringPropertyUpdated[reponame][propertyName] = propertyValue
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, reponame string, topics []string) {
+	r.RepositoryTopicsUpdated[reponame] = topics
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, reponame string, branch string) {
+	r.RepositoryDefaultBranchUpdated[reponame] = branch
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	r.RepositoriesSetExternalUser[githubid] = permission
@@ -1082,7 +1106,7 @@ func TestReconciliation(t *testing.T) {
 		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
 	})
 
-	t.Run("happy path: remove a team from an existing repo", func(t *testing.T) {
+	t.Run("happy path: add a triage team to an existing repo with a maintain team", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
 		repoconf := config.RepositoryConfig{}
@@ -1098,21 +1122,19 @@ func TestReconciliation(t *testing.T) {
 		lRepo.Name = "myrepo"
 		lRepo.Spec.Readers = []string{}
 		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.TriageTeams = []string{"triager"}
+		lRepo.Spec.MaintainTeams = []string{"maintainer"}
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
 
-		existingTeam := &entity.Team{}
-		existingTeam.Name = "existing"
-		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{"existing_member"}
-		local.teams["existing"] = existingTeam
-
-		readerTeam := &entity.Team{}
-		readerTeam.Name = "reader"
-		readerTeam.Spec.Owners = []string{"existing_owner"}
-		readerTeam.Spec.Members = []string{"existing_member"}
-		local.teams["reader"] = readerTeam
+		for _, teamname := range []string{"existing", "triager", "maintainer"} {
+			team := &entity.Team{}
+			team.Name = teamname
+			team.Spec.Owners = []string{"existing_owner"}
+			team.Spec.Members = []string{"existing_member"}
+			local.teams[teamname] = team
+		}
 
 		remote := GoliacRemoteMock{
 			users:      make(map[string]string),
@@ -1127,18 +1149,13 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
-			Name:    "existing",
-			Slug:    "existing",
-			Members: []string{"existing_owner", "existing_member"},
-		}
-		reader := &GithubTeam{
-			Name:    "reader",
-			Slug:    "reader",
-			Members: []string{"existing_owner", "existing_member"},
+		for _, teamname := range []string{"existing", "triager", "maintainer"} {
+			remote.teams[teamname] = &GithubTeam{
+				Name:    teamname,
+				Slug:    teamname,
+				Members: []string{"existing_owner", "existing_member"},
+			}
 		}
-		remote.teams["existing"] = existing
-		remote.teams["reader"] = reader
 		rRepo := GithubRepository{
 			Name:           "myrepo",
 			ExternalUsers:  map[string]string{},
@@ -1149,26 +1166,25 @@ func TestReconciliation(t *testing.T) {
 		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
 		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
 			Name:       "myrepo",
-			Permission: "WRITE",
+			Permission: "ADMIN",
 		}
-		remote.teamsrepos["reader"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["reader"]["myrepo"] = &GithubTeamRepo{
+		remote.teamsrepos["maintainer"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["maintainer"]["myrepo"] = &GithubTeamRepo{
 			Name:       "myrepo",
-			Permission: "WRITE",
+			Permission: "MAINTAIN",
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 team removed
+		// the triage team is added, the maintain team is left untouched
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 1, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, []string{"triager"}, recorder.RepositoryTeamAdded["myrepo"])
 		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
 	})
 
-	t.Run("happy path: remove a team member", func(t *testing.T) {
+	t.Run("happy path: update the default branch of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
 		repoconf := config.RepositoryConfig{}
@@ -1182,22 +1198,20 @@ func TestReconciliation(t *testing.T) {
 		}
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{}
-		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.DefaultBranch = "develop"
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
-		existingUser := entity.User{}
-		existingUser.Spec.GithubID = "existing_member"
-		local.users["existing_member"] = &existingUser
-		existingOwner := entity.User{}
-		existingOwner.Spec.GithubID = "existing_owner"
-		local.users["existing_owner"] = &existingOwner
+
+		// not managed by goliac: left untouched
+		lUnmanaged := &entity.Repository{}
+		lUnmanaged.Name = "unmanaged"
+		lUnmanaged.Owner = &lowner
+		local.repos["unmanaged"] = lUnmanaged
 
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{}
 		local.teams["existing"] = existingTeam
 
 		remote := GoliacRemoteMock{
@@ -1213,38 +1227,32 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
+		remote.teams["existing"] = &GithubTeam{
 			Name:    "existing",
 			Slug:    "existing",
-			Members: []string{"existing_owner", "existing_member"},
-		}
-		remote.teams["existing"] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  map[string]string{},
-			BoolProperties: map[string]bool{},
+			Members: []string{"existing_owner"},
 		}
-		remote.repos["myrepo"] = &rRepo
-
 		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
-			Name:       "myrepo",
-			Permission: "WRITE",
+		for _, reponame := range []string{"myrepo", "unmanaged"} {
+			remote.repos[reponame] = &GithubRepository{
+				Name:           reponame,
+				DefaultBranch:  "main",
+				ExternalUsers:  map[string]string{},
+				BoolProperties: map[string]bool{},
+			}
+			remote.teamsrepos["existing"][reponame] = &GithubTeamRepo{
+				Name:       reponame,
+				Permission: "ADMIN",
+			}
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 member removed
-		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
-		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
-		assert.Equal(t, 1, len(recorder.TeamMemberRemoved))
+		assert.Equal(t, map[string]string{"myrepo": "develop"}, recorder.RepositoryDefaultBranchUpdated)
 	})
 
-	t.Run("happy path: update a team member from maintainer to member", func(t *testing.T) {
+	t.Run("happy path: update the merge methods of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
 		repoconf := config.RepositoryConfig{}
@@ -1256,24 +1264,18 @@ func TestReconciliation(t *testing.T) {
 			teams: make(map[string]*entity.Team),
 			repos: make(map[string]*entity.Repository),
 		}
+		disabled := false
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{}
-		lRepo.Spec.Writers = []string{}
+		// allow_merge_commit is not set: not managed by goliac
+		lRepo.Spec.AllowSquashMerge = &disabled
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
-		existingUser := entity.User{}
-		existingUser.Spec.GithubID = "existing_member"
-		local.users["existing_member"] = &existingUser
-		existingOwner := entity.User{}
-		existingOwner.Spec.GithubID = "existing_owner"
-		local.users["existing_owner"] = &existingOwner
 
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{"existing_member"}
 		local.teams["existing"] = existingTeam
 
 		remote := GoliacRemoteMock{
@@ -1289,42 +1291,40 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
-			Name:        "existing",
-			Slug:        "existing",
-			Members:     []string{"existing_member"},
-			Maintainers: []string{"existing_owner"},
+		remote.teams["existing"] = &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner"},
 		}
-		remote.teams["existing"] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  map[string]string{},
-			BoolProperties: map[string]bool{},
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:          "myrepo",
+			ExternalUsers: map[string]string{},
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+				"allow_merge_commit":     false,
+				"allow_squash_merge":     true,
+			},
 		}
-		remote.repos["myrepo"] = &rRepo
-
-		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
-			Name:       "myrepo",
-			Permission: "WRITE",
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 member removed
-		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
-		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
-		fmt.Println("**debug", recorder.TeamMemberRemoved)
-		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
-		assert.Equal(t, 1, len(recorder.TeamMemberUpdated))
+		assert.Equal(t, map[string]bool{"allow_squash_merge": false}, recorder.RepositoryBoolPropertyUpdated["myrepo"])
 	})
 
-	t.Run("happy path: add a team AND add it to an existing repo", func(t *testing.T) {
+	t.Run("happy path: update the merge commit title of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
+
 		repoconf := config.RepositoryConfig{}
 
 		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
@@ -1336,8 +1336,8 @@ func TestReconciliation(t *testing.T) {
 		}
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{"reader"}
-		lRepo.Spec.Writers = []string{}
+		// merge_commit_message is not set: not managed by goliac
+		lRepo.Spec.MergeCommitTitle = "PR_TITLE"
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
@@ -1345,15 +1345,8 @@ func TestReconciliation(t *testing.T) {
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{"existing_member"}
 		local.teams["existing"] = existingTeam
 
-		readerTeam := &entity.Team{}
-		readerTeam.Name = "reader"
-		readerTeam.Spec.Owners = []string{"existing_owner"}
-		readerTeam.Spec.Members = []string{"existing_member"}
-		local.teams["reader"] = readerTeam
-
 		remote := GoliacRemoteMock{
 			users:      make(map[string]string),
 			teams:      make(map[string]*GithubTeam),
@@ -1367,37 +1360,43 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
+		remote.teams["existing"] = &GithubTeam{
 			Name:    "existing",
 			Slug:    "existing",
-			Members: []string{"existing_owner", "existing_member"},
+			Members: []string{"existing_owner"},
 		}
-		remote.teams["existing"] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  map[string]string{},
-			BoolProperties: map[string]bool{},
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:          "myrepo",
+			ExternalUsers: map[string]string{},
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+			StringProperties: map[string]string{
+				"merge_commit_title":   "MERGE_MESSAGE",
+				"merge_commit_message": "PR_TITLE",
+			},
 		}
-		remote.repos["myrepo"] = &rRepo
-
-		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
-			Name:       "myrepo",
-			Permission: "WRITE",
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 repo updated
-		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 2, len(recorder.RepositoryTeamAdded))
+		assert.Nil(t, recorder.RepositoryBoolPropertyUpdated["myrepo"])
+		assert.Equal(t, map[string]string{"merge_commit_title": "PR_TITLE"}, recorder.RepositoryStringPropertyUpdated["myrepo"])
 	})
 
-	t.Run("happy path: add a externally managed team AND add it to an existing repo", func(t *testing.T) {
+	t.Run("happy path: update the topics of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
+
 		repoconf := config.RepositoryConfig{}
 
 		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
@@ -1409,23 +1408,23 @@ func TestReconciliation(t *testing.T) {
 		}
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{"newerTeam"}
-		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.Topics = []string{"golang", "github"}
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
 
+		// the topics are compared regardless of their order
+		lOtherRepo := &entity.Repository{}
+		lOtherRepo.Name = "otherrepo"
+		lOtherRepo.Spec.Topics = []string{"golang", "github"}
+		lOtherRepo.Owner = &lowner
+		local.repos["otherrepo"] = lOtherRepo
+
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{"existing_member"}
 		local.teams["existing"] = existingTeam
 
-		newerTeam := &entity.Team{}
-		newerTeam.Name = "newerTeam"
-		newerTeam.Spec.ExternallyManaged = true
-		local.teams["newerTeam"] = newerTeam
-
 		remote := GoliacRemoteMock{
 			users:      make(map[string]string),
 			teams:      make(map[string]*GithubTeam),
@@ -1439,38 +1438,46 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
+		remote.teams["existing"] = &GithubTeam{
 			Name:    "existing",
 			Slug:    "existing",
-			Members: []string{"existing_owner", "existing_member"},
-		}
-		remote.teams["existing"] = existing
-		remote.teams["existing"+config.Config.GoliacTeamOwnerSuffix] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  map[string]string{},
-			BoolProperties: map[string]bool{},
+			Members: []string{"existing_owner"},
 		}
-		remote.repos["myrepo"] = &rRepo
-
-		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
-			Name:       "myrepo",
-			Permission: "WRITE",
+		for reponame, topics := range map[string][]string{
+			"myrepo":    {"golang"},
+			"otherrepo": {"github", "golang"},
+		} {
+			remote.repos[reponame] = &GithubRepository{
+				Name:          reponame,
+				ExternalUsers: map[string]string{},
+				BoolProperties: map[string]bool{
+					"private":                true,
+					"archived":               false,
+					"allow_auto_merge":       false,
+					"delete_branch_on_merge": false,
+					"allow_update_branch":    false,
+				},
+				Topics: topics,
+			}
+		}
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
+			"otherrepo": {
+				Name:       "otherrepo",
+				Permission: "ADMIN",
+			},
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 repo updated
-		assert.Equal(t, 1, len(recorder.TeamsCreated)) // the newerTeam-goliac-owners team
-		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 2, len(recorder.RepositoryTeamAdded))
+		assert.Equal(t, map[string][]string{"myrepo": {"golang", "github"}}, recorder.RepositoryTopicsUpdated)
 	})
 
-	t.Run("happy path: existing repo with new external write collaborator", func(t *testing.T) {
+	t.Run("happy path: enable the discussions of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
 		repoconf := config.RepositoryConfig{}
@@ -1478,21 +1485,15 @@ func TestReconciliation(t *testing.T) {
 		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
 
 		local := GoliacLocalMock{
-			users:     make(map[string]*entity.User),
-			externals: make(map[string]*entity.User),
-			teams:     make(map[string]*entity.Team),
-			repos:     make(map[string]*entity.Repository),
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
 		}
-		outside1 := entity.User{}
-		outside1.Name = "outside1"
-		outside1.Spec.GithubID = "outside1-githubid"
-		local.externals["outside1"] = &outside1
-
+		enabled := true
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{}
-		lRepo.Spec.Writers = []string{}
-		lRepo.Spec.ExternalUserWriters = []string{"outside1"}
+		// has_issues is not set: not managed by goliac
+		lRepo.Spec.HasDiscussions = &enabled
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
@@ -1500,7 +1501,6 @@ func TestReconciliation(t *testing.T) {
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{}
 		local.teams["existing"] = existingTeam
 
 		remote := GoliacRemoteMock{
@@ -1516,39 +1516,38 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
+		remote.teams["existing"] = &GithubTeam{
 			Name:    "existing",
 			Slug:    "existing",
 			Members: []string{"existing_owner"},
 		}
-		remote.teams["existing"] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  make(map[string]string),
-			BoolProperties: make(map[string]bool),
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:          "myrepo",
+			ExternalUsers: map[string]string{},
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+				"has_issues":             false,
+				"has_discussions":        false,
+			},
 		}
-		remote.repos["myrepo"] = &rRepo
-
-		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
-		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
-			Name:       "myrepo",
-			Permission: "WRITE",
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
 		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
 
-		// 1 team updated
-		assert.Equal(t, 0, len(recorder.RepositoryCreated))
-		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
-		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
-		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
-		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
-		assert.Equal(t, 1, len(recorder.RepositoriesSetExternalUser))
-		assert.Equal(t, 0, len(recorder.RepositoriesRemoveExternalUser))
+		assert.Equal(t, map[string]bool{"has_discussions": true}, recorder.RepositoryBoolPropertyUpdated["myrepo"])
 	})
 
-	t.Run("happy path: existing repo with deleted external write collaborator", func(t *testing.T) {
+	t.Run("happy path: require the web commit signoff of an existing repo", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
 		repoconf := config.RepositoryConfig{}
@@ -1556,17 +1555,14 @@ func TestReconciliation(t *testing.T) {
 		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
 
 		local := GoliacLocalMock{
-			users:     make(map[string]*entity.User),
-			externals: make(map[string]*entity.User),
-			teams:     make(map[string]*entity.Team),
-			repos:     make(map[string]*entity.Repository),
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
 		}
-
+		enabled := true
 		lRepo := &entity.Repository{}
 		lRepo.Name = "myrepo"
-		lRepo.Spec.Readers = []string{}
-		lRepo.Spec.Writers = []string{}
-		lRepo.Spec.ExternalUserWriters = []string{}
+		lRepo.Spec.WebCommitSignoffRequired = &enabled
 		lowner := "existing"
 		lRepo.Owner = &lowner
 		local.repos["myrepo"] = lRepo
@@ -1574,7 +1570,6 @@ func TestReconciliation(t *testing.T) {
 		existingTeam := &entity.Team{}
 		existingTeam.Name = "existing"
 		existingTeam.Spec.Owners = []string{"existing_owner"}
-		existingTeam.Spec.Members = []string{}
 		local.teams["existing"] = existingTeam
 
 		remote := GoliacRemoteMock{
@@ -1590,17 +1585,705 @@ func TestReconciliation(t *testing.T) {
 			ExternalUsers:  map[string]string{},
 			BoolProperties: map[string]bool{},
 		}
-		existing := &GithubTeam{
+		remote.teams["existing"] = &GithubTeam{
 			Name:    "existing",
 			Slug:    "existing",
 			Members: []string{"existing_owner"},
 		}
-		remote.teams["existing"] = existing
-		rRepo := GithubRepository{
-			Name:           "myrepo",
-			ExternalUsers:  make(map[string]string),
-			BoolProperties: make(map[string]bool),
-		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:          "myrepo",
+			ExternalUsers: map[string]string{},
+			BoolProperties: map[string]bool{
+				"private":                     true,
+				"archived":                    false,
+				"allow_auto_merge":            false,
+				"delete_branch_on_merge":      false,
+				"allow_update_branch":         false,
+				"web_commit_signoff_required": false,
+			},
+		}
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		assert.Equal(t, map[string]bool{"web_commit_signoff_required": true}, recorder.RepositoryBoolPropertyUpdated["myrepo"])
+	})
+
+	t.Run("happy path: disable the dependabot alerts of an existing repo", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		disabled := false
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.DependabotAlerts = &disabled
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.teams["existing"] = &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner"},
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:          "myrepo",
+			ExternalUsers: map[string]string{},
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+				"dependabot_alerts":      true,
+			},
+		}
+		remote.teamsrepos["existing"] = map[string]*GithubTeamRepo{
+			"myrepo": {
+				Name:       "myrepo",
+				Permission: "ADMIN",
+			},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		assert.Equal(t, map[string]bool{"dependabot_alerts": false}, recorder.RepositoryBoolPropertyUpdated["myrepo"])
+	})
+
+	t.Run("happy path: remove a team from an existing repo", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{"existing_member"}
+		local.teams["existing"] = existingTeam
+
+		readerTeam := &entity.Team{}
+		readerTeam.Name = "reader"
+		readerTeam.Spec.Owners = []string{"existing_owner"}
+		readerTeam.Spec.Members = []string{"existing_member"}
+		local.teams["reader"] = readerTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner", "existing_member"},
+		}
+		reader := &GithubTeam{
+			Name:    "reader",
+			Slug:    "reader",
+			Members: []string{"existing_owner", "existing_member"},
+		}
+		remote.teams["existing"] = existing
+		remote.teams["reader"] = reader
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+		remote.teamsrepos["reader"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["reader"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 team removed
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+	})
+
+	t.Run("happy path: remove a team member", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+		existingUser := entity.User{}
+		existingUser.Spec.GithubID = "existing_member"
+		local.users["existing_member"] = &existingUser
+		existingOwner := entity.User{}
+		existingOwner.Spec.GithubID = "existing_owner"
+		local.users["existing_owner"] = &existingOwner
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner", "existing_member"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 member removed
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+		assert.Equal(t, 1, len(recorder.TeamMemberRemoved))
+	})
+
+	t.Run("happy path: update a team member from maintainer to member", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+		existingUser := entity.User{}
+		existingUser.Spec.GithubID = "existing_member"
+		local.users["existing_member"] = &existingUser
+		existingOwner := entity.User{}
+		existingOwner.Spec.GithubID = "existing_owner"
+		local.users["existing_owner"] = &existingOwner
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{"existing_member"}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:        "existing",
+			Slug:        "existing",
+			Members:     []string{"existing_member"},
+			Maintainers: []string{"existing_owner"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 member removed
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+		fmt.Println("**debug", recorder.TeamMemberRemoved)
+		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
+		assert.Equal(t, 1, len(recorder.TeamMemberUpdated))
+	})
+
+	t.Run("happy path: add a team AND add it to an existing repo", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{"reader"}
+		lRepo.Spec.Writers = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{"existing_member"}
+		local.teams["existing"] = existingTeam
+
+		readerTeam := &entity.Team{}
+		readerTeam.Name = "reader"
+		readerTeam.Spec.Owners = []string{"existing_owner"}
+		readerTeam.Spec.Members = []string{"existing_member"}
+		local.teams["reader"] = readerTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner", "existing_member"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 repo updated
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 2, len(recorder.RepositoryTeamAdded))
+	})
+
+	t.Run("happy path: add a externally managed team AND add it to an existing repo", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{"newerTeam"}
+		lRepo.Spec.Writers = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{"existing_member"}
+		local.teams["existing"] = existingTeam
+
+		newerTeam := &entity.Team{}
+		newerTeam.Name = "newerTeam"
+		newerTeam.Spec.ExternallyManaged = true
+		local.teams["newerTeam"] = newerTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner", "existing_member"},
+		}
+		remote.teams["existing"] = existing
+		remote.teams["existing"+config.Config.GoliacTeamOwnerSuffix] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 repo updated
+		assert.Equal(t, 1, len(recorder.TeamsCreated)) // the newerTeam-goliac-owners team
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 2, len(recorder.RepositoryTeamAdded))
+	})
+
+	t.Run("happy path: existing repo with new external write collaborator", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			externals: make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+		}
+		outside1 := entity.User{}
+		outside1.Name = "outside1"
+		outside1.Spec.GithubID = "outside1-githubid"
+		local.externals["outside1"] = &outside1
+
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.ExternalUserWriters = []string{"outside1"}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  make(map[string]string),
+			BoolProperties: make(map[string]bool),
+		}
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 team updated
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+		assert.Equal(t, 1, len(recorder.RepositoriesSetExternalUser))
+		assert.Equal(t, 0, len(recorder.RepositoriesRemoveExternalUser))
+	})
+
+	t.Run("happy path: existing repo with deleted external write collaborator", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			externals: make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+		}
+
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.ExternalUserWriters = []string{}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  make(map[string]string),
+			BoolProperties: make(map[string]bool),
+		}
+		rRepo.ExternalUsers["outside1-githubid"] = "WRITE"
+		remote.repos["myrepo"] = &rRepo
+
+		remote.teamsrepos["existing"] = make(map[string]*GithubTeamRepo)
+		remote.teamsrepos["existing"]["myrepo"] = &GithubTeamRepo{
+			Name:       "myrepo",
+			Permission: "WRITE",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		// 1 team updated
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded)) // on teams repo
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+		assert.Equal(t, 0, len(recorder.RepositoriesSetExternalUser))
+		assert.Equal(t, 1, len(recorder.RepositoriesRemoveExternalUser))
+	})
+
+	t.Run("happy path: existing repo with expired external write collaborator", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			externals: make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+		}
+
+		outside1 := entity.User{}
+		outside1.Name = "outside1"
+		outside1.Spec.GithubID = "outside1-githubid"
+		local.externals["outside1"] = &outside1
+
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Readers = []string{}
+		lRepo.Spec.Writers = []string{}
+		lRepo.Spec.ExternalUserWriters = []string{"outside1"}
+		// the grant expired: it must be revoked
+		lRepo.ExternalUserWritersExpiry = map[string]string{"outside1": "2001-01-01"}
+		lowner := "existing"
+		lRepo.Owner = &lowner
+		local.repos["myrepo"] = lRepo
+
+		existingTeam := &entity.Team{}
+		existingTeam.Name = "existing"
+		existingTeam.Spec.Owners = []string{"existing_owner"}
+		existingTeam.Spec.Members = []string{}
+		local.teams["existing"] = existingTeam
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["teams"] = &GithubRepository{
+			Name:           "teams",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{},
+		}
+		existing := &GithubTeam{
+			Name:    "existing",
+			Slug:    "existing",
+			Members: []string{"existing_owner"},
+		}
+		remote.teams["existing"] = existing
+		rRepo := GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  make(map[string]string),
+			BoolProperties: make(map[string]bool),
+		}
 		rRepo.ExternalUsers["outside1-githubid"] = "WRITE"
 		remote.repos["myrepo"] = &rRepo
 
@@ -2096,6 +2779,148 @@ func TestReconciliationRulesets(t *testing.T) {
 		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
 	})
 
+	t.Run("happy path: update ruleset (status check integration)", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{
+			Rulesets: make([]struct {
+				Pattern string
+				Ruleset string
+			}, 0),
+		}
+		repoconf.Rulesets = append(repoconf.Rulesets, struct {
+			Pattern string
+			Ruleset string
+		}{
+			Pattern: ".*",
+			Ruleset: "checks",
+		})
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+
+		lRuleset := &entity.RuleSet{}
+		lRuleset.Name = "checks"
+		lRuleset.Spec.Enforcement = "active"
+		lRuleset.Spec.Rules = append(lRuleset.Spec.Rules, struct {
+			Ruletype   string
+			Parameters entity.RuleSetParameters `yaml:"parameters,omitempty"`
+		}{
+			"required_status_checks", entity.RuleSetParameters{
+				RequiredStatusChecks:             []string{"circleCI check"},
+				RequiredStatusChecksIntegrations: map[string]int{"circleCI check": 1234},
+			},
+		})
+		local.rulesets["checks"] = lRuleset
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		rRuleset := &GithubRuleSet{
+			Name:        "checks",
+			Enforcement: "active",
+			Rules:       make(map[string]entity.RuleSetParameters),
+		}
+		// the status check is not pinned to its integration on Github
+		rRuleset.Rules["required_status_checks"] = entity.RuleSetParameters{
+			RequiredStatusChecks: []string{"circleCI check"},
+		}
+		remote.rulesets["checks"] = rRuleset
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	t.Run("happy path: update ruleset (properties conditions)", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{
+			Rulesets: make([]struct {
+				Pattern string
+				Ruleset string
+			}, 0),
+		}
+		repoconf.Rulesets = append(repoconf.Rulesets, struct {
+			Pattern string
+			Ruleset string
+		}{
+			Pattern: "^repo",
+			Ruleset: "tier1",
+		})
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		for reponame, tier := range map[string]string{"repo1": "1", "repo2": "2"} {
+			repo := &entity.Repository{}
+			repo.Name = reponame
+			repo.Spec.Properties = map[string]string{"tier": tier}
+			local.repos[reponame] = repo
+			remote.repos[reponame] = &GithubRepository{
+				Name:           reponame,
+				ExternalUsers:  map[string]string{},
+				InternalUsers:  map[string]string{},
+				BoolProperties: map[string]bool{},
+			}
+		}
+
+		lRuleset := &entity.RuleSet{}
+		lRuleset.Name = "tier1"
+		lRuleset.Spec.Enforcement = "active"
+		lRuleset.Spec.Conditions.Properties = map[string]string{"tier": "1"}
+		lRuleset.Spec.Rules = append(lRuleset.Spec.Rules, struct {
+			Ruletype   string
+			Parameters entity.RuleSetParameters `yaml:"parameters,omitempty"`
+		}{
+			"required_signatures", entity.RuleSetParameters{},
+		})
+		local.rulesets["tier1"] = lRuleset
+
+		// the ruleset was applied to both repositories before the tier condition
+		rRuleset := &GithubRuleSet{
+			Name:         "tier1",
+			Enforcement:  "active",
+			Rules:        map[string]entity.RuleSetParameters{"required_signatures": {}},
+			Repositories: []string{"repo1", "repo2"},
+		}
+		remote.rulesets["tier1"] = rRuleset
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, "goliac-admin", toArchive, map[string]*entity.Repository{})
+
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		assert.Equal(t, []string{"repo1"}, recorder.RuleSetUpdated["tier1"].Repositories)
+	})
+
 	t.Run("happy path: delete ruleset", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
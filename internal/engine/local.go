@@ -289,9 +289,16 @@ func (g *GoliacLocalImpl) LoadRepoConfig() (*config.RepositoryConfig, error) {
 		return nil, err
 	}
 
+	return readRepoConfig(w.Filesystem)
+}
+
+/*
+ * readRepoConfig reads the /goliac.yaml configuration file
+ */
+func readRepoConfig(fs billy.Filesystem) (*config.RepositoryConfig, error) {
 	var repoconfig config.RepositoryConfig
 
-	content, err := utils.ReadFile(w.Filesystem, "goliac.yaml")
+	content, err := utils.ReadFile(fs, "goliac.yaml")
 	if err != nil {
 		return nil, fmt.Errorf("not able to find the /goliac.yaml configuration file: %v", err)
 	}
@@ -437,11 +444,24 @@ func (g *GoliacLocalImpl) UpdateRepos(reposToArchiveList []string, reposToRename
 	if len(reposToRename) != 0 {
 
 		for directoryPath, repository := range reposToRename {
-			newRepository := *repository
-			newRepository.Name = repository.RenameTo
-			newRepository.RenameTo = ""
+			source := repository.Filename
+			if source == "" {
+				source = filepath.Join(directoryPath, repository.Name+".yaml")
+			}
+			// rewrite the file as authored (and not the loaded repository, that
+			// contains the merged included fragments)
+			document, err := renamedRepositoryDocument(w.Filesystem, source, repository.RenameTo)
+			if err != nil {
+				return err
+			}
 
-			filename := filepath.Join(directoryPath, newRepository.Name+".yaml")
+			// the file is renamed only if it is named after the repository
+			// (keeping its extension), else it is rewritten in place
+			filename := source
+			ext := filepath.Ext(source)
+			if strings.TrimSuffix(filepath.Base(source), ext) == repository.Name {
+				filename = filepath.Join(filepath.Dir(source), repository.RenameTo+ext)
+			}
 			file, err := w.Filesystem.Create(filename)
 			if err != nil {
 				return fmt.Errorf("not able to create file %s: %v", filename, err)
@@ -450,7 +470,7 @@ func (g *GoliacLocalImpl) UpdateRepos(reposToArchiveList []string, reposToRename
 
 			encoder := yaml.NewEncoder(file)
 			encoder.SetIndent(2)
-			err = encoder.Encode(&newRepository)
+			err = encoder.Encode(document)
 			if err != nil {
 				return fmt.Errorf("not able to write to file %s: %v", filename, err)
 			}
@@ -460,9 +480,11 @@ func (g *GoliacLocalImpl) UpdateRepos(reposToArchiveList []string, reposToRename
 				return err
 			}
 
-			_, err = w.Remove(filepath.Join(directoryPath, repository.Name+".yaml"))
-			if err != nil {
-				return err
+			if filename != source {
+				_, err = w.Remove(source)
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -495,6 +517,38 @@ func (g *GoliacLocalImpl) UpdateRepos(reposToArchiveList []string, reposToRename
 	return g.PushTag(tagname, headRef.Hash(), accesstoken)
 }
 
+/*
+ * renamedRepositoryDocument reads a repository file and returns its yaml
+ * document with the new name (and without the renameTo directive)
+ */
+func renamedRepositoryDocument(fs billy.Filesystem, filename string, newname string) (*yaml.Node, error) {
+	filecontent, err := utils.ReadFile(fs, filename)
+	if err != nil {
+		return nil, fmt.Errorf("not able to read file %s: %v", filename, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(filecontent, &document); err != nil {
+		return nil, fmt.Errorf("not able to parse file %s: %v", filename, err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid repository file %s", filename)
+	}
+
+	root := document.Content[0]
+	content := []*yaml.Node{}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		switch root.Content[i].Value {
+		case "renameTo":
+			continue
+		case "name":
+			root.Content[i+1].Value = newname
+		}
+		content = append(content, root.Content[i], root.Content[i+1])
+	}
+	root.Content = content
+	return &document, nil
+}
+
 /*
  * UpdateAndCommitCodeOwners will collects all teams definition to update the .github/CODEOWNERS file
  * cf https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners
@@ -704,7 +758,7 @@ func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig,
 	// let's update teams
 	//
 
-	errors, _ := g.loadUsers(w.Filesystem)
+	errors, _ := g.loadUsers(w.Filesystem, repoconfig)
 	if len(errors) > 0 {
 		return false, fmt.Errorf("cannot read users (for example: %v)", errors[0])
 	}
@@ -803,37 +857,15 @@ func (g *GoliacLocalImpl) LoadAndValidate() ([]error, []entity.Warning) {
 	return errs, warns
 }
 
-func (g *GoliacLocalImpl) loadUsers(fs billy.Filesystem) ([]error, []entity.Warning) {
-	errors := []error{}
-	warnings := []entity.Warning{}
-
-	// Parse all the users in the <orgDirectory>/protected-users directory
-	protectedUsers, errs, warns := entity.ReadUserDirectory(fs, filepath.Join("users", "protected"))
-	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-	g.users = protectedUsers
-
-	// Parse all the users in the <orgDirectory>/org-users directory
-	orgUsers, errs, warns := entity.ReadUserDirectory(fs, filepath.Join("users", "org"))
-	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-
-	// not users? not good
-	if orgUsers == nil {
-		return errors, warnings
-	}
-
-	for k, v := range orgUsers {
-		g.users[k] = v
-	}
+func (g *GoliacLocalImpl) loadUsers(fs billy.Filesystem, repoconfig *config.RepositoryConfig) ([]error, []entity.Warning) {
+	users, externalUsers, errors, warnings := entity.ReadOrganizationUsers(fs)
+	g.users = users
+	g.externalUsers = externalUsers
 
-	// Parse all the users in the <orgDirectory>/external-users directory
-	externalUsers, errs, warns := entity.ReadUserDirectory(fs, filepath.Join("users", "external"))
+	opts, errs := entity.NewValidationOptions(repoconfig)
 	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-	g.externalUsers = externalUsers
 
-	rulesets, errs, warns := entity.ReadRuleSetDirectory(fs, filepath.Join("rulesets"))
+	rulesets, errs, warns := entity.ReadRuleSetDirectory(fs, filepath.Join("rulesets"), opts)
 	errors = append(errors, errs...)
 	warnings = append(warnings, warns...)
 	g.rulesets = rulesets
@@ -847,28 +879,24 @@ func (g *GoliacLocalImpl) loadUsers(fs billy.Filesystem) ([]error, []entity.Warn
  * - a slice of warning that must not stop the validation process
  */
 func (g *GoliacLocalImpl) LoadAndValidateLocal(fs billy.Filesystem) ([]error, []entity.Warning) {
-	errors, warnings := g.loadUsers(fs)
-
-	if len(errors) > 0 {
-		return errors, warnings
+	repoconfig, err := readRepoConfig(fs)
+	if err != nil {
+		return []error{err}, []entity.Warning{}
 	}
 
-	// Parse all the teams in the <orgDirectory>/teams directory
-	teams, errs, warns := entity.ReadTeamDirectory(fs, "teams", g.users)
-	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-	g.teams = teams
+	opts, errs := entity.NewValidationOptions(repoconfig)
+	if len(errs) > 0 {
+		return errs, []entity.Warning{}
+	}
 
-	// Parse all repositories in the <orgDirectory>/teams/<teamname> directories
-	repos, errs, warns := entity.ReadRepositories(fs, "archived", "teams", g.teams, g.externalUsers)
-	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-	g.repositories = repos
+	org, errors, warnings := entity.LoadOrganization(fs, nil, opts)
+	g.users = org.Users
+	g.externalUsers = org.ExternalUsers
+	g.teams = org.Teams
+	g.repositories = org.Repositories
+	g.rulesets = org.RuleSets
 
-	rulesets, errs, warns := entity.ReadRuleSetDirectory(fs, "rulesets")
-	errors = append(errors, errs...)
-	warnings = append(warnings, warns...)
-	g.rulesets = rulesets
+	errors = append(errors, entity.ValidateRulesetMappings(repoconfig, org.RuleSets)...)
 
 	logrus.Debugf("Nb local users: %d", len(g.users))
 	logrus.Debugf("Nb local external users: %d", len(g.externalUsers))
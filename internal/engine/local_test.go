@@ -101,6 +101,84 @@ func TestRepository(t *testing.T) {
 		assert.Equal(t, 0, len(warns))
 	})
 
+	t.Run("not happy path: goliac.yaml referencing an unknown ruleset", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+rulesets:
+- pattern: .*
+  ruleset: default
+`), 0644)
+		assert.Nil(t, err)
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "ruleset default")
+	})
+
+	t.Run("not happy path: warning promoted to an error in goliac.yaml", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "teams/team2/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team2
+spec:
+  owners:
+  - user1
+`), 0644)
+		assert.Nil(t, err)
+		g := NewGoliacLocalImpl()
+		errs, warns := g.LoadAndValidateLocal(fs)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+
+		err = utils.WriteFile(fs, "goliac.yaml", []byte(`
+validation:
+  error_on_warnings:
+  - not-enough-owners
+`), 0644)
+		assert.Nil(t, err)
+		errs, warns = g.LoadAndValidateLocal(fs)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(warns))
+	})
+
+	t.Run("not happy path: reserved repository name set in goliac.yaml", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "teams/team1/legacy.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: legacy
+`), 0644)
+		assert.Nil(t, err)
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+		assert.Equal(t, 0, len(errs))
+
+		err = utils.WriteFile(fs, "goliac.yaml", []byte(`
+validation:
+  repository_reserved_names:
+  - legacy
+`), 0644)
+		assert.Nil(t, err)
+		errs, _ = g.LoadAndValidateLocal(fs)
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "reserved name")
+	})
+
+	t.Run("not happy path: missing goliac.yaml", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		fs.Remove("goliac.yaml")
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 1, len(errs))
+	})
+
 	t.Run("happy path: local repository", func(t *testing.T) {
 		fs := memfs.New()
 		storer := memory.NewStorage()
@@ -738,6 +816,57 @@ func TestGoliacLocalImpl(t *testing.T) {
 		assert.Equal(t, "apiVersion: v1\nkind: Repository\nname: repo1\n", string(content))
 	})
 
+	t.Run("RenameRepos: .yml repository", func(t *testing.T) {
+		rootfs := memfs.New()
+		src, _ := rootfs.Chroot("/src")
+		target, _ := src.Chroot("/target")
+
+		repo, clonedRepo, err := helperCreateAndClone(rootfs, src, target)
+		assert.Nil(t, err)
+		assert.NotNil(t, repo)
+		assert.NotNil(t, clonedRepo)
+
+		// add a .yml repository definition
+		err = utils.WriteFile(target, "teams/team1/repo1.yml", []byte("apiVersion: v1\nkind: Repository\nname: repo1\nrenameTo: repo2\n"), 0644)
+		assert.Nil(t, err)
+		w, err := clonedRepo.Worktree()
+		assert.Nil(t, err)
+		_, err = w.Add(".")
+		assert.Nil(t, err)
+		_, err = w.Commit("add repo1", &git.CommitOptions{
+			Author: &object.Signature{
+				Name:  "Goliac",
+				Email: config.Config.GoliacEmail,
+				When:  time.Now(),
+			},
+		})
+		assert.Nil(t, err)
+
+		g := GoliacLocalImpl{
+			teams:         map[string]*entity.Team{},
+			repositories:  map[string]*entity.Repository{},
+			users:         map[string]*entity.User{},
+			externalUsers: map[string]*entity.User{},
+			rulesets:      map[string]*entity.RuleSet{},
+			repo:          clonedRepo,
+		}
+
+		repo1 := &entity.Repository{}
+		repo1.Name = "repo1"
+		repo1.RenameTo = "repo2"
+		repo1.DirectoryPath = "teams/team1"
+		repo1.Filename = "teams/team1/repo1.yml"
+		err = g.UpdateRepos([]string{}, map[string]*entity.Repository{"teams/team1": repo1}, "none", "master", "foobar")
+		assert.Nil(t, err)
+
+		content, err := utils.ReadFile(target, "teams/team1/repo2.yml")
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: v1\nkind: Repository\nname: repo2\n", string(content))
+		exist, err := utils.Exists(target, "teams/team1/repo1.yml")
+		assert.Nil(t, err)
+		assert.False(t, exist)
+	})
+
 	t.Run("UpdateAndCommitCodeOwners", func(t *testing.T) {
 		rootfs := memfs.New()
 		src, _ := rootfs.Chroot("/src")
@@ -171,9 +171,10 @@ func (m *MutableGoliacRemoteImpl) DeleteTeam(teamslug string) {
 }
 func (m *MutableGoliacRemoteImpl) CreateRepository(reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool) {
 	r := GithubRepository{
-		Name:           reponame,
-		BoolProperties: boolProperties,
-		ExternalUsers:  map[string]string{},
+		Name:             reponame,
+		BoolProperties:   boolProperties,
+		StringProperties: map[string]string{},
+		ExternalUsers:    map[string]string{},
 	}
 	m.repositories[reponame] = &r
 }
@@ -231,12 +232,43 @@ UpdateRepositoryUpdateBoolProperty is used for
 - allow_auto_merge
 - delete_branch_on_merge
 - allow_update_branch
+- allow_merge_commit
+- allow_squash_merge
+- allow_rebase_merge
+- has_issues
+- has_discussions
+- web_commit_signoff_required
+- dependabot_alerts
 */
 func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(reponame string, propertyName string, propertyValue bool) {
 	if r, ok := m.repositories[reponame]; ok {
 		r.BoolProperties[propertyName] = propertyValue
 	}
 }
+
+/*
+UpdateRepositoryUpdateStringProperty is used for
+- merge_commit_title
+- merge_commit_message
+*/
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateStringProperty(reponame string, propertyName string, propertyValue string) {
+	if r, ok := m.repositories[reponame]; ok {
+		if r.StringProperties == nil {
+			r.StringProperties = make(map[string]string)
+		}
+		r.StringProperties[propertyName] = propertyValue
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateTopics(reponame string, topics []string) {
+	if r, ok := m.repositories[reponame]; ok {
+		r.Topics = topics
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateDefaultBranch(reponame string, branch string) {
+	if r, ok := m.repositories[reponame]; ok {
+		r.DefaultBranch = branch
+	}
+}
 func (m *MutableGoliacRemoteImpl) UpdateRepositorySetExternalUser(reponame string, collaboatorGithubId string, permission string) {
 	if r, ok := m.repositories[reponame]; ok {
 		r.ExternalUsers[collaboatorGithubId] = permission
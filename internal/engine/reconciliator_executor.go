@@ -15,8 +15,11 @@ type ReconciliatorExecutor interface {
 
 	CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool)
 	UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool)
-	UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string)    // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
-	UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
+	UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string)
+	UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, reponame string, topics []string)
+	UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, reponame string, branch string)
+	UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string)    // permission can be "pull", "triage", "push", "maintain", or "admin"
+	UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) // permission can be "pull", "triage", "push", "maintain", or "admin"
 	UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string)
 	AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet)
 	UpdateRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet)
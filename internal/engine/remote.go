@@ -58,18 +58,22 @@ type GoliacRemoteExecutor interface {
 }
 
 type GithubRepository struct {
-	Name           string
-	Id             int
-	RefId          string
-	BoolProperties map[string]bool           // archived, private, allow_auto_merge, delete_branch_on_merge, allow_update_branch
-	ExternalUsers  map[string]string         // [githubid]permission
-	InternalUsers  map[string]string         // [githubid]permission
-	RuleSets       map[string]*GithubRuleSet // [name]ruleset
+	Name             string
+	Id               int
+	RefId            string
+	BoolProperties   map[string]bool   // archived, private, allow_auto_merge, delete_branch_on_merge, allow_update_branch, allow_merge_commit, allow_squash_merge, allow_rebase_merge, has_issues, has_discussions, web_commit_signoff_required, dependabot_alerts
+	StringProperties map[string]string // merge_commit_title, merge_commit_message
+	DefaultBranch    string            // default branch name
+	Topics           []string
+	ExternalUsers    map[string]string         // [githubid]permission
+	InternalUsers    map[string]string         // [githubid]permission
+	RuleSets         map[string]*GithubRuleSet // [name]ruleset
 }
 
 type GithubTeam struct {
 	Name        string
 	Id          int
+	NodeId      string // GraphQL id (i.e. to resolve the rulesets required reviewers)
 	Slug        string
 	Members     []string // user login, aka githubid
 	Maintainers []string // user login (that are not in the Members array)
@@ -496,6 +500,25 @@ query listAllReposInOrg($orgLogin: String!, $endCursor: String) {
 		  autoMergeAllowed
           deleteBranchOnMerge
           allowUpdateBranch
+          mergeCommitAllowed
+          squashMergeAllowed
+          rebaseMergeAllowed
+          webCommitSignoffRequired
+          hasVulnerabilityAlertsEnabled
+          hasDiscussionsEnabled
+          hasIssuesEnabled
+          mergeCommitTitle
+          mergeCommitMessage
+          defaultBranchRef {
+            name
+          }
+          repositoryTopics(first: 20) {
+            nodes {
+              topic {
+                name
+              }
+            }
+          }
           directCollaborators: collaborators(affiliation: DIRECT, first: 100) {
             edges {
               node {
@@ -561,14 +584,33 @@ type GraplQLRepositories struct {
 		Organization struct {
 			Repositories struct {
 				Nodes []struct {
-					Name                string
-					Id                  string
-					DatabaseId          int
-					IsArchived          bool
-					IsPrivate           bool
-					AutoMergeAllowed    bool
-					DeleteBranchOnMerge bool
-					AllowUpdateBranch   bool
+					Name                          string
+					Id                            string
+					DatabaseId                    int
+					IsArchived                    bool
+					IsPrivate                     bool
+					AutoMergeAllowed              bool
+					DeleteBranchOnMerge           bool
+					AllowUpdateBranch             bool
+					MergeCommitAllowed            bool
+					SquashMergeAllowed            bool
+					RebaseMergeAllowed            bool
+					WebCommitSignoffRequired      bool
+					HasVulnerabilityAlertsEnabled bool
+					HasDiscussionsEnabled         bool
+					HasIssuesEnabled              bool
+					MergeCommitTitle              string
+					MergeCommitMessage            string
+					DefaultBranchRef              struct {
+						Name string
+					}
+					RepositoryTopics struct {
+						Nodes []struct {
+							Topic struct {
+								Name string
+							}
+						}
+					}
 					DirectCollaborators struct {
 						Edges []struct {
 							Node struct {
@@ -641,16 +683,31 @@ func (g *GoliacRemoteImpl) loadRepositories(ctx context.Context) (map[string]*Gi
 				Id:    c.DatabaseId,
 				RefId: c.Id,
 				BoolProperties: map[string]bool{
-					"archived":               c.IsArchived,
-					"private":                c.IsPrivate,
-					"allow_auto_merge":       c.AutoMergeAllowed,
-					"delete_branch_on_merge": c.DeleteBranchOnMerge,
-					"allow_update_branch":    c.AllowUpdateBranch,
+					"archived":                    c.IsArchived,
+					"private":                     c.IsPrivate,
+					"allow_auto_merge":            c.AutoMergeAllowed,
+					"delete_branch_on_merge":      c.DeleteBranchOnMerge,
+					"allow_update_branch":         c.AllowUpdateBranch,
+					"allow_merge_commit":          c.MergeCommitAllowed,
+					"allow_squash_merge":          c.SquashMergeAllowed,
+					"allow_rebase_merge":          c.RebaseMergeAllowed,
+					"web_commit_signoff_required": c.WebCommitSignoffRequired,
+					"dependabot_alerts":           c.HasVulnerabilityAlertsEnabled,
+					"has_discussions":             c.HasDiscussionsEnabled,
+					"has_issues":                  c.HasIssuesEnabled,
 				},
+				StringProperties: map[string]string{
+					"merge_commit_title":   c.MergeCommitTitle,
+					"merge_commit_message": c.MergeCommitMessage,
+				},
+				DefaultBranch: c.DefaultBranchRef.Name,
 				ExternalUsers: make(map[string]string),
 				InternalUsers: make(map[string]string),
 				RuleSets:      make(map[string]*GithubRuleSet),
 			}
+			for _, topic := range c.RepositoryTopics.Nodes {
+				repo.Topics = append(repo.Topics, topic.Topic.Name)
+			}
 			for _, outsideCollaborator := range c.OutsideCollaborators.Edges {
 				repo.ExternalUsers[outsideCollaborator.Node.Login] = outsideCollaborator.Permission
 			}
@@ -691,6 +748,7 @@ query listAllTeamsInOrg($orgLogin: String!, $endCursor: String) {
       teams(first: 100, after: $endCursor) {
         nodes {
           name
+		  id
 		  databaseId
           slug
 		  parentTeam {
@@ -713,7 +771,8 @@ type GraplQLTeams struct {
 			Teams struct {
 				Nodes []struct {
 					Name       string
-					DatabaseId int `json:"databaseId"`
+					Id         string `json:"id"`
+					DatabaseId int    `json:"databaseId"`
 					Slug       string
 					ParentTeam struct {
 						DatabaseId int `json:"databaseId"`
@@ -1055,8 +1114,12 @@ func (g *GoliacRemoteImpl) loadTeamRepos(ctx context.Context, repository string)
 		switch t.Permission {
 		case "admin":
 			permission = "ADMIN"
+		case "maintain":
+			permission = "MAINTAIN"
 		case "push":
 			permission = "WRITE"
+		case "triage":
+			permission = "TRIAGE"
 		case "pull":
 			permission = "READ"
 		}
@@ -1150,9 +1213,10 @@ func (g *GoliacRemoteImpl) loadTeams(ctx context.Context) (map[string]*GithubTea
 
 		for _, c := range gResult.Data.Organization.Teams.Nodes {
 			team := GithubTeam{
-				Name: c.Name,
-				Id:   c.DatabaseId,
-				Slug: c.Slug,
+				Name:   c.Name,
+				Id:     c.DatabaseId,
+				NodeId: c.Id,
+				Slug:   c.Slug,
 			}
 			if c.ParentTeam.DatabaseId != 0 {
 				parentId := c.ParentTeam.DatabaseId
@@ -1323,6 +1387,16 @@ query listRulesets ($orgLogin: String!) {
 						requiredApprovingReviewCount
 						requiredReviewThreadResolution
 						requireLastPushApproval
+						requiredReviewers {
+							reviewerId
+						}
+					}
+					... on RequiredStatusChecksParameters {
+						requiredStatusChecks {
+							context
+							integrationId
+						}
+						strictRequiredStatusChecksPolicy
 					}
 				}
 				type
@@ -1347,6 +1421,10 @@ type GithubRuleSetApp struct {
 	BypassMode string // ALWAYS, PULL_REQUEST
 }
 
+type GithubRuleSetRuleReviewer struct {
+	ReviewerId string // team GraphQL id
+}
+
 type GithubRuleSetRuleStatusCheck struct {
 	Context       string
 	IntegrationId int
@@ -1360,6 +1438,7 @@ type GithubRuleSetRule struct {
 		RequiredApprovingReviewCount   int
 		RequiredReviewThreadResolution bool
 		RequireLastPushApproval        bool
+		RequiredReviewers              []GithubRuleSetRuleReviewer
 
 		// RequiredStatusChecksParameters
 		RequiredStatusChecks             []GithubRuleSetRuleStatusCheck
@@ -1425,6 +1504,7 @@ type GraplQLRuleSets struct {
 type GithubRuleSet struct {
 	Name        string
 	Id          int               // for tracking purpose
+	Target      string            // branch (default), tag
 	Enforcement string            // disabled, active, evaluate
 	BypassApps  map[string]string // appname, mode (always, pull_request)
 
@@ -1440,6 +1520,7 @@ func (g *GoliacRemoteImpl) fromGraphQLToGithubRuleset(src *GraphQLGithubRuleSet)
 	ruleset := GithubRuleSet{
 		Name:         src.Name,
 		Id:           src.DatabaseId,
+		Target:       strings.ToLower(src.Target),
 		Enforcement:  strings.ToLower(src.Enforcement),
 		BypassApps:   map[string]string{},
 		OnInclude:    src.Conditions.RefName.Include,
@@ -1460,8 +1541,22 @@ func (g *GoliacRemoteImpl) fromGraphQLToGithubRuleset(src *GraphQLGithubRuleSet)
 			RequireLastPushApproval:          r.Parameters.RequireLastPushApproval,
 			StrictRequiredStatusChecksPolicy: r.Parameters.StrictRequiredStatusChecksPolicy,
 		}
+		for _, reviewer := range r.Parameters.RequiredReviewers {
+			for _, team := range g.teams {
+				if team.NodeId == reviewer.ReviewerId {
+					rule.RequiredReviewers = append(rule.RequiredReviewers, team.Name)
+					break
+				}
+			}
+		}
 		for _, s := range r.Parameters.RequiredStatusChecks {
 			rule.RequiredStatusChecks = append(rule.RequiredStatusChecks, s.Context)
+			if s.IntegrationId != 0 {
+				if rule.RequiredStatusChecksIntegrations == nil {
+					rule.RequiredStatusChecksIntegrations = map[string]int{}
+				}
+				rule.RequiredStatusChecksIntegrations[s.Context] = s.IntegrationId
+			}
 		}
 		ruleset.Rules[strings.ToLower(r.Type)] = rule
 	}
@@ -1577,6 +1672,20 @@ func (g *GoliacRemoteImpl) prepareRuleset(ruleset *GithubRuleSet) map[string]int
 				"type": "deletion",
 			})
 		case "pull_request":
+			// each required reviewer team must approve the changes of any file
+			reviewers := make([]map[string]interface{}, 0, len(rule.RequiredReviewers))
+			for _, teamname := range rule.RequiredReviewers {
+				if team, ok := g.teams[g.teamSlugByName[teamname]]; ok {
+					reviewers = append(reviewers, map[string]interface{}{
+						"file_patterns":     []string{"*"},
+						"minimum_approvals": 1,
+						"reviewer": map[string]interface{}{
+							"id":   team.Id,
+							"type": "Team",
+						},
+					})
+				}
+			}
 			rules = append(rules, map[string]interface{}{
 				"type": "pull_request",
 				"parameters": map[string]interface{}{
@@ -1585,22 +1694,38 @@ func (g *GoliacRemoteImpl) prepareRuleset(ruleset *GithubRuleSet) map[string]int
 					"required_approving_review_count":   rule.RequiredApprovingReviewCount,
 					"required_review_thread_resolution": rule.RequiredReviewThreadResolution,
 					"require_last_push_approval":        rule.RequireLastPushApproval,
+					"required_reviewers":                reviewers,
 				},
 			})
 		case "required_status_checks":
+			// pin the status checks to their integration (if any)
+			checks := make([]map[string]interface{}, 0, len(rule.RequiredStatusChecks))
+			for _, statusCheck := range rule.RequiredStatusChecks {
+				check := map[string]interface{}{
+					"context": statusCheck,
+				}
+				if id, ok := rule.RequiredStatusChecksIntegrations[statusCheck]; ok {
+					check["integration_id"] = id
+				}
+				checks = append(checks, check)
+			}
 			rules = append(rules, map[string]interface{}{
 				"type": "required_status_checks",
 				"parameters": map[string]interface{}{
-					"required_status_checks":               rule.RequiredStatusChecks,
+					"required_status_checks":               checks,
 					"strict_required_status_checks_policy": rule.StrictRequiredStatusChecksPolicy,
 				},
 			})
 		}
 	}
 
+	target := ruleset.Target
+	if target == "" {
+		target = "branch"
+	}
 	payload := map[string]interface{}{
 		"name":          ruleset.Name,
-		"target":        "branch",
+		"target":        target,
 		"enforcement":   ruleset.Enforcement,
 		"bypass_actors": bypassActors,
 		"conditions":    conditions,
@@ -2051,6 +2176,10 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 			"description": description,
 		}
 		for k, v := range boolProperties {
+			// the dependabot alerts are set once the repository exists
+			if k == "dependabot_alerts" {
+				continue
+			}
 			props[k] = v
 		}
 
@@ -2079,14 +2208,19 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 
 	// update the repositories list
 	newRepo := &GithubRepository{
-		Name:           reponame,
-		Id:             repoId,
-		RefId:          repoRefId,
-		BoolProperties: boolProperties,
+		Name:             reponame,
+		Id:               repoId,
+		RefId:            repoRefId,
+		BoolProperties:   boolProperties,
+		StringProperties: map[string]string{},
 	}
 	g.repositories[reponame] = newRepo
 	g.repositoriesByRefId[repoRefId] = newRepo
 
+	if enabled, ok := boolProperties["dependabot_alerts"]; ok {
+		g.updateRepositoryVulnerabilityAlerts(ctx, dryrun, reponame, enabled)
+	}
+
 	// add members
 	for _, reader := range readers {
 		// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#add-or-update-team-repository-permissions
@@ -2161,17 +2295,32 @@ func (g *GoliacRemoteImpl) UpdateRepositoryAddTeamAccess(ctx context.Context, dr
 	if teamsRepos == nil {
 		teamsRepos = make(map[string]*GithubTeamRepo)
 	}
-	rPermission := "READ"
-	if permission == "push" {
-		rPermission = "WRITE"
-	}
 	teamsRepos[reponame] = &GithubTeamRepo{
 		Name:       reponame,
-		Permission: rPermission,
+		Permission: teamRepoPermission(permission),
 	}
 	g.teamRepos[teamslug] = teamsRepos
 }
 
+/*
+ * teamRepoPermission converts a REST team permission ("pull", "triage",
+ * "push", "maintain" or "admin") to its GraphQL form (READ, TRIAGE, ...)
+ */
+func teamRepoPermission(permission string) string {
+	switch permission {
+	case "admin":
+		return "ADMIN"
+	case "maintain":
+		return "MAINTAIN"
+	case "push":
+		return "WRITE"
+	case "triage":
+		return "TRIAGE"
+	default:
+		return "READ"
+	}
+}
+
 func (g *GoliacRemoteImpl) UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) {
 	// update member
 	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#add-or-update-team-repository-permissions
@@ -2192,13 +2341,9 @@ func (g *GoliacRemoteImpl) UpdateRepositoryUpdateTeamAccess(ctx context.Context,
 	if teamsRepos == nil {
 		teamsRepos = make(map[string]*GithubTeamRepo)
 	}
-	rPermission := "READ"
-	if permission == "push" {
-		rPermission = "WRITE"
-	}
 	teamsRepos[reponame] = &GithubTeamRepo{
 		Name:       reponame,
-		Permission: rPermission,
+		Permission: teamRepoPermission(permission),
 	}
 	g.teamRepos[teamslug] = teamsRepos
 }
@@ -2231,9 +2376,20 @@ Used for
 - allow_auto_merge
 - delete_branch_on_merge
 - allow_update_branch
+- allow_merge_commit
+- allow_squash_merge
+- allow_rebase_merge
+- web_commit_signoff_required
+- dependabot_alerts
+- has_discussions
+- has_issues
 - archived
 */
 func (g *GoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
+	if propertyName == "dependabot_alerts" {
+		g.updateRepositoryVulnerabilityAlerts(ctx, dryrun, reponame, propertyValue)
+		return
+	}
 	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
 	if !dryrun {
 		body, err := g.client.CallRestAPI(
@@ -2253,6 +2409,102 @@ func (g *GoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Contex
 	}
 }
 
+/*
+updateRepositoryVulnerabilityAlerts enables or disables the Dependabot
+alerts, which are not part of the repository update endpoint
+*/
+func (g *GoliacRemoteImpl) updateRepositoryVulnerabilityAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#enable-vulnerability-alerts
+	if !dryrun {
+		method := "PUT"
+		if !enabled {
+			method = "DELETE"
+		}
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("repos/%s/%s/vulnerability-alerts", config.Config.GithubAppOrganization, reponame),
+			"",
+			method,
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update repository dependabot_alerts setting: %v. %s", err, string(body))
+		}
+	}
+
+	if repo, ok := g.repositories[reponame]; ok {
+		repo.BoolProperties["dependabot_alerts"] = enabled
+	}
+}
+
+/*
+Used for
+- merge_commit_title
+- merge_commit_message
+*/
+func (g *GoliacRemoteImpl) UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+			"",
+			"PATCH",
+			map[string]interface{}{propertyName: propertyValue},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update repository %s setting: %v. %s", propertyName, err, string(body))
+		}
+	}
+
+	if repo, ok := g.repositories[reponame]; ok {
+		if repo.StringProperties == nil {
+			repo.StringProperties = make(map[string]string)
+		}
+		repo.StringProperties[propertyName] = propertyValue
+	}
+}
+
+func (g *GoliacRemoteImpl) UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, reponame string, topics []string) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#replace-all-repository-topics
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("repos/%s/%s/topics", config.Config.GithubAppOrganization, reponame),
+			"",
+			"PUT",
+			map[string]interface{}{"names": topics},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update repository %s topics: %v. %s", reponame, err, string(body))
+		}
+	}
+
+	if repo, ok := g.repositories[reponame]; ok {
+		repo.Topics = topics
+	}
+}
+
+func (g *GoliacRemoteImpl) UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, reponame string, branch string) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+			"",
+			"PATCH",
+			map[string]interface{}{"default_branch": branch},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update repository %s default branch: %v. %s", reponame, err, string(body))
+		}
+	}
+
+	if repo, ok := g.repositories[reponame]; ok {
+		repo.DefaultBranch = branch
+	}
+}
+
 func (g *GoliacRemoteImpl) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	// https://docs.github.com/en/rest/collaborators/collaborators?apiVersion=2022-11-28#add-a-repository-collaborator
 	if !dryrun {
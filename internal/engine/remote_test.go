@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/entity"
 	"github.com/Alayacare/goliac/internal/github"
 	"github.com/stretchr/testify/assert"
 
@@ -103,6 +104,7 @@ func (m *MockGithubClient) reposNodes(first, after string, args ast.ArgumentList
 	searchName, _ := hasChild("name", children)
 	searchArchived, _ := hasChild("isArchived", children)
 	searchPrivate, _ := hasChild("isPrivate", children)
+	searchVulnerabilityAlerts, _ := hasChild("hasVulnerabilityAlertsEnabled", children)
 
 	index := iAfter
 	totalCount := 0
@@ -121,6 +123,9 @@ func (m *MockGithubClient) reposNodes(first, after string, args ast.ArgumentList
 		if searchPrivate {
 			block["isPrivate"] = index%10 == 0 // let's pretend each 10 repo is a private repo
 		}
+		if searchVulnerabilityAlerts {
+			block["hasVulnerabilityAlertsEnabled"] = index%2 == 0 // let's pretend each 2 repo has the dependabot alerts
+		}
 		index++
 		if index > maxToFake { // let's pretend we have maxToFake repos
 			hasNext = false
@@ -449,6 +454,8 @@ func TestRemoteRepository(t *testing.T) {
 		assert.Equal(t, true, repositories["repo_3"].BoolProperties["archived"])
 		assert.Equal(t, false, repositories["repo_1"].BoolProperties["private"])
 		assert.Equal(t, true, repositories["repo_10"].BoolProperties["private"])
+		assert.Equal(t, false, repositories["repo_1"].BoolProperties["dependabot_alerts"])
+		assert.Equal(t, true, repositories["repo_2"].BoolProperties["dependabot_alerts"])
 	})
 	t.Run("happy path: load remote teams", func(t *testing.T) {
 		// MockGithubClient doesn't support concurrent access
@@ -616,3 +623,77 @@ func TestIsEnterprise(t *testing.T) {
 		}
 	})
 }
+
+func TestRemoteRuleset(t *testing.T) {
+
+	t.Run("happy path: status checks are pinned to their integration", func(t *testing.T) {
+		client := MockGithubClient{}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		ruleset := &GithubRuleSet{
+			Name:        "checks",
+			Enforcement: "active",
+			Rules: map[string]entity.RuleSetParameters{
+				"required_status_checks": {
+					RequiredStatusChecks:             []string{"circleCI check", "jenkins check"},
+					RequiredStatusChecksIntegrations: map[string]int{"circleCI check": 1234},
+				},
+			},
+		}
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		rules := payload["rules"].([]map[string]interface{})
+		assert.Equal(t, 1, len(rules))
+		checks := rules[0]["parameters"].(map[string]interface{})["required_status_checks"].([]map[string]interface{})
+		assert.Equal(t, 2, len(checks))
+		assert.Equal(t, map[string]interface{}{"context": "circleCI check", "integration_id": 1234}, checks[0])
+		assert.Equal(t, map[string]interface{}{"context": "jenkins check"}, checks[1])
+	})
+
+	t.Run("happy path: status checks integrations are read back", func(t *testing.T) {
+		client := MockGithubClient{}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		rule := GithubRuleSetRule{Type: "REQUIRED_STATUS_CHECKS"}
+		rule.Parameters.RequiredStatusChecks = []GithubRuleSetRuleStatusCheck{
+			{Context: "circleCI check", IntegrationId: 1234},
+			{Context: "jenkins check"},
+		}
+		src := GraphQLGithubRuleSet{Name: "checks", Enforcement: "ACTIVE"}
+		src.Rules.Nodes = append(src.Rules.Nodes, rule)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRuleset(&src)
+		params := ruleset.Rules["required_status_checks"]
+		assert.Equal(t, []string{"circleCI check", "jenkins check"}, params.RequiredStatusChecks)
+		assert.Equal(t, map[string]int{"circleCI check": 1234}, params.RequiredStatusChecksIntegrations)
+	})
+	t.Run("happy path: required reviewers are sent as teams ids and read back", func(t *testing.T) {
+		client := MockGithubClient{}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.teams["security"] = &GithubTeam{Name: "security", Id: 42, NodeId: "T_42", Slug: "security"}
+		remoteImpl.teamSlugByName["security"] = "security"
+
+		ruleset := &GithubRuleSet{
+			Name:        "reviews",
+			Enforcement: "active",
+			Rules: map[string]entity.RuleSetParameters{
+				"pull_request": {
+					RequiredReviewers: []string{"security", "unknown"},
+				},
+			},
+		}
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		rules := payload["rules"].([]map[string]interface{})
+		reviewers := rules[0]["parameters"].(map[string]interface{})["required_reviewers"].([]map[string]interface{})
+		assert.Equal(t, 1, len(reviewers))
+		assert.Equal(t, map[string]interface{}{"id": 42, "type": "Team"}, reviewers[0]["reviewer"])
+
+		rule := GithubRuleSetRule{Type: "PULL_REQUEST"}
+		rule.Parameters.RequiredReviewers = []GithubRuleSetRuleReviewer{{ReviewerId: "T_42"}}
+		src := GraphQLGithubRuleSet{Name: "reviews", Enforcement: "ACTIVE"}
+		src.Rules.Nodes = append(src.Rules.Nodes, rule)
+
+		assert.Equal(t, []string{"security"}, remoteImpl.fromGraphQLToGithubRuleset(&src).Rules["pull_request"].RequiredReviewers)
+	})
+}
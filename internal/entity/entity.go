@@ -1,6 +1,15 @@
 package entity
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"gopkg.in/yaml.v3"
@@ -8,6 +17,235 @@ import (
 
 type Warning error
 
+/*
+ * CodedWarning is a Warning identified by a code, so specific warnings
+ * can be promoted to errors (see ValidationOptions.ErrorOnWarningCodes)
+ */
+type CodedWarning struct {
+	Code string
+	Err  error
+}
+
+func (w *CodedWarning) Error() string {
+	return w.Err.Error()
+}
+
+func (w *CodedWarning) Unwrap() error {
+	return w.Err
+}
+
+func NewCodedWarning(code string, format string, a ...interface{}) Warning {
+	return &CodedWarning{
+		Code: code,
+		Err:  fmt.Errorf(format, a...),
+	}
+}
+
+/*
+ * PromoteWarnings splits the warnings between the ones whose code is part of
+ * codes (returned as errors) and the others (still returned as warnings)
+ */
+func PromoteWarnings(warnings []Warning, codes []string) ([]error, []Warning) {
+	promoted := []error{}
+	remaining := []Warning{}
+
+	promote := make(map[string]bool)
+	for _, code := range codes {
+		promote[code] = true
+	}
+	for _, warning := range warnings {
+		var coded *CodedWarning
+		if errors.As(warning, &coded) && promote[coded.Code] {
+			promoted = append(promoted, warning)
+		} else {
+			remaining = append(remaining, warning)
+		}
+	}
+	return promoted, remaining
+}
+
+/*
+ * ValidationOptions holds the organization policies applied when reading and
+ * validating the entities. The zero value doesn't enforce any policy
+ */
+type ValidationOptions struct {
+	// ruleset features (rule types) available with the organization Github plan (if nil, all features are available)
+	RuleSetAvailableFeatures map[string]bool
+	// warning codes promoted to errors when loading the organization (by default, no warning is promoted)
+	ErrorOnWarningCodes []string
+	// repository name expected for a repository filename (if nil, the filename without its extension)
+	RepositoryNameFromFile func(path string) string
+	// maximum nesting depth of the team directories, a top level team is at depth 1 (0 means unlimited)
+	RepositoryMaxTeamDepth int
+	// naming policy the repositories names must match, i.e. ^[a-z]+-[a-z0-9-]+$ (nil: no policy)
+	RepositoryNamePattern *regexp.Regexp
+	// Github App integration ids allowed to provide required status checks (if nil, any app is allowed)
+	AllowedStatusChecksIntegrations map[int]bool
+	// make the owning team a required reviewer of every inline pull_request ruleset of its repositories
+	OwnerRequiredReviewer bool
+	// Github Apps names -> repositories visibility they can operate on, "internal" for internal-only apps (if nil, the apps are not checked)
+	AppVisibilityScopes map[string]string
+	// custom properties keys defined in the organization (if nil, any key is accepted)
+	RepositoryPropertyKeys map[string]bool
+	// maximum number of rules in a ruleset, can be lowered to match the Github limit (0: RuleSetDefaultMaxRules)
+	RuleSetMaxRules int
+	// names a repository cannot have, compared case insensitively (if nil, RepositoryDefaultReservedNames)
+	RepositoryReservedNames []string
+	// variables substituted in the repositories spec (${NAME}), next to ${TEAM} set to the owning team name
+	Variables map[string]string
+	// an unresolved ${NAME} placeholder in a repository spec is an error
+	StrictVariables bool
+}
+
+/*
+ * NewValidationOptions returns the validation options set in the
+ * validation section of the goliac configuration (goliac.yaml)
+ */
+func NewValidationOptions(repoconfig *config.RepositoryConfig) (ValidationOptions, []error) {
+	errors := []error{}
+	conf := repoconfig.Validation
+
+	opts := ValidationOptions{
+		ErrorOnWarningCodes:     conf.ErrorOnWarnings,
+		RepositoryMaxTeamDepth:  conf.RepositoryMaxTeamDepth,
+		OwnerRequiredReviewer:   conf.OwnerRequiredReviewer,
+		AppVisibilityScopes:     conf.AppVisibilityScopes,
+		RuleSetMaxRules:         conf.RulesetMaxRules,
+		RepositoryReservedNames: conf.RepositoryReservedNames,
+		Variables:               conf.Variables,
+		StrictVariables:         conf.StrictVariables,
+	}
+
+	if conf.RepositoryNamePattern != "" {
+		pattern, err := regexp.Compile(conf.RepositoryNamePattern)
+		if err != nil {
+			errors = append(errors, withFile("goliac.yaml", fmt.Errorf("invalid validation.repository_name_pattern %s: %v", conf.RepositoryNamePattern, err)))
+		} else {
+			opts.RepositoryNamePattern = pattern
+		}
+	}
+	if conf.RepositoryNameFromFilename != "" {
+		pattern, err := regexp.Compile(conf.RepositoryNameFromFilename)
+		if err != nil {
+			errors = append(errors, withFile("goliac.yaml", fmt.Errorf("invalid validation.repository_name_from_filename %s: %v", conf.RepositoryNameFromFilename, err)))
+		} else if pattern.NumSubexp() < 1 {
+			errors = append(errors, withFile("goliac.yaml", fmt.Errorf("invalid validation.repository_name_from_filename %s: it must capture the repository name", conf.RepositoryNameFromFilename)))
+		} else {
+			// a filename not matching the pattern keeps the default convention
+			opts.RepositoryNameFromFile = func(path string) string {
+				filename := filepath.Base(path)
+				if m := pattern.FindStringSubmatch(filename); m != nil {
+					return m[1]
+				}
+				return filename[:len(filename)-len(filepath.Ext(filename))]
+			}
+		}
+	}
+	if conf.RulesetAvailableFeatures != nil {
+		opts.RuleSetAvailableFeatures = make(map[string]bool)
+		for _, feature := range conf.RulesetAvailableFeatures {
+			opts.RuleSetAvailableFeatures[feature] = true
+		}
+	}
+	if conf.AllowedStatusChecksIntegrations != nil {
+		opts.AllowedStatusChecksIntegrations = make(map[int]bool)
+		for _, id := range conf.AllowedStatusChecksIntegrations {
+			opts.AllowedStatusChecksIntegrations[id] = true
+		}
+	}
+	if conf.RepositoryPropertyKeys != nil {
+		opts.RepositoryPropertyKeys = make(map[string]bool)
+		for _, key := range conf.RepositoryPropertyKeys {
+			opts.RepositoryPropertyKeys[key] = true
+		}
+	}
+	return opts, errors
+}
+
+/*
+ * ruleSetMaxRules returns the maximum number of rules in a ruleset
+ */
+func (o ValidationOptions) ruleSetMaxRules() int {
+	if o.RuleSetMaxRules > 0 {
+		return o.RuleSetMaxRules
+	}
+	return RuleSetDefaultMaxRules
+}
+
+/*
+ * repositoryVariables returns the variables substituted in the spec of the
+ * repositories owned by teamName ("" for the archived repositories)
+ */
+func (o ValidationOptions) repositoryVariables(teamName string) map[string]string {
+	variables := make(map[string]string, len(o.Variables)+1)
+	for k, v := range o.Variables {
+		variables[k] = v
+	}
+	if teamName != "" {
+		variables["TEAM"] = teamName
+	}
+	return variables
+}
+
+/*
+ * repositoryReservedName returns the reserved name matching the repository
+ * name (case insensitively), or "" if the name is not reserved
+ */
+func (o ValidationOptions) repositoryReservedName(name string) string {
+	reserved := o.RepositoryReservedNames
+	if reserved == nil {
+		reserved = RepositoryDefaultReservedNames
+	}
+	for _, r := range reserved {
+		if strings.EqualFold(name, r) {
+			return r
+		}
+	}
+	return ""
+}
+
+/*
+ * ParseError is returned when a file cannot be parsed (i.e. a YAML syntax
+ * error), as opposed to the validation errors returned by Validate
+ * Kind is "syntax" for malformed YAML, "type" when a value doesn't match
+ * the expected field type
+ */
+type ParseError struct {
+	File string
+	Line int // 0 if unknown
+	Kind string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("cannot parse %s: %v", e.File, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var parseErrorLine = regexp.MustCompile(`line (\d+)`)
+
+/*
+ * newParseError wraps a yaml unmarshalling error into a ParseError
+ */
+func newParseError(filename string, err error) error {
+	pe := &ParseError{
+		File: filename,
+		Kind: "syntax",
+		Err:  err,
+	}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		pe.Kind = "type"
+	}
+	if m := parseErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		pe.Line, _ = strconv.Atoi(m[1])
+	}
+	return pe
+}
+
 type Entity struct {
 	ApiVersion string `yaml:"apiVersion"`
 	Kind       string `yaml:"kind"`
@@ -27,8 +265,179 @@ func parseEntity(fs billy.Filesystem, filename string) (*Entity, error) {
 	yamldata := &Entity{}
 	err = yaml.Unmarshal(filecontent, yamldata)
 	if err != nil {
-		return nil, err
+		return nil, newParseError(filename, err)
 	}
 
 	return yamldata, nil
 }
+
+/*
+ * fieldDeprecations lists the deprecated fields (spec yaml key -> replacement)
+ * of the repositories and of the rulesets (also checked in the repositories
+ * inline rulesets): setting one of them is still supported, but raises a
+ * warning naming the replacement
+ */
+type fieldDeprecations struct {
+	repository map[string]string
+	ruleset    map[string]string
+}
+
+/*
+ * defaultFieldDeprecations returns the deprecations applied when reading the
+ * files. The rulesets bypassapps -> bypassActors migration will be added with
+ * the bypassActors field
+ */
+func defaultFieldDeprecations() fieldDeprecations {
+	return fieldDeprecations{
+		repository: map[string]string{"public": "visibility"},
+		ruleset:    map[string]string{},
+	}
+}
+
+/*
+ * findDeprecatedFields returns the deprecated keys (-> replacement) set in
+ * a yaml mapping node, prefixed by prefix
+ */
+func findDeprecatedFields(node *yaml.Node, deprecated map[string]string, prefix string, found map[string]string) map[string]string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return found
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if replacement, ok := deprecated[node.Content[i].Value]; ok {
+			if found == nil {
+				found = make(map[string]string)
+			}
+			found[prefix+node.Content[i].Value] = replacement
+		}
+	}
+	return found
+}
+
+/*
+ * deprecationWarnings returns one warning per deprecated field, sorted by field
+ */
+func deprecationWarnings(fields map[string]string, filename string) []Warning {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	warnings := []Warning{}
+	for _, name := range names {
+		warnings = append(warnings, NewCodedWarning("deprecated-field", "field %s is deprecated, use %s instead (check filename %s)", name, fields[name], filename))
+	}
+	return warnings
+}
+
+/*
+ * FileError is a validation error (or warning) attached to the file it is
+ * about. File is the path of the file as it was read
+ */
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ * withFile attaches err to file, unless err is nil or is already attached
+ * to a file
+ */
+func withFile(file string, err error) error {
+	if err == nil || validationFile(err) != "" {
+		return err
+	}
+	return &FileError{File: file, Err: err}
+}
+
+/*
+ * warningsWithFile attaches all the warnings to file (see withFile)
+ */
+func warningsWithFile(file string, warnings []Warning) []Warning {
+	for i, warning := range warnings {
+		warnings[i] = withFile(file, warning)
+	}
+	return warnings
+}
+
+/*
+ * validationFile returns the file an error or a warning is about ("" if unknown)
+ */
+func validationFile(err error) string {
+	var fe *FileError
+	if errors.As(err, &fe) {
+		return fe.File
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return pe.File
+	}
+	return ""
+}
+
+/*
+ * FormatValidationResults returns a human readable report of the validation
+ * errors and warnings, grouped by file (sorted, the messages without a file
+ * last) and followed by a summary line. The output is stable, suitable for CI logs
+ * color enables the ANSI colors (i.e. for a terminal)
+ */
+func FormatValidationResults(errs []error, warns []Warning, color bool) string {
+	type line struct {
+		severity string
+		message  string
+	}
+	byFile := make(map[string][]line)
+	for _, err := range errs {
+		file := validationFile(err)
+		byFile[file] = append(byFile[file], line{"error", err.Error()})
+	}
+	for _, warn := range warns {
+		file := validationFile(warn)
+		byFile[file] = append(byFile[file], line{"warning", warn.Error()})
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	if _, ok := byFile[""]; ok {
+		files = append(files, "")
+	}
+
+	colors := map[string]string{"error": "\033[31m", "warning": "\033[33m"}
+	var report strings.Builder
+	for _, file := range files {
+		if file == "" {
+			report.WriteString("(no file)\n")
+		} else {
+			report.WriteString(file + "\n")
+		}
+		lines := byFile[file]
+		sort.SliceStable(lines, func(i, j int) bool {
+			if lines[i].severity != lines[j].severity {
+				return lines[i].severity == "error"
+			}
+			return lines[i].message < lines[j].message
+		})
+		for _, l := range lines {
+			severity := l.severity
+			if color {
+				severity = colors[l.severity] + severity + "\033[0m"
+			}
+			report.WriteString(fmt.Sprintf("  %s: %s\n", severity, l.message))
+		}
+	}
+	report.WriteString(fmt.Sprintf("%d error(s), %d warning(s)\n", len(errs), len(warns)))
+	return report.String()
+}
@@ -1,9 +1,16 @@
 package entity
 
 import (
+	"bytes"
 	"fmt"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"gopkg.in/yaml.v3"
@@ -11,15 +18,20 @@ import (
 
 type RuleSetParameters struct {
 	// PullRequestParameters
-	DismissStaleReviewsOnPush      bool `yaml:"dismissStaleReviewsOnPush,omitempty"`
-	RequireCodeOwnerReview         bool `yaml:"requireCodeOwnerReview,omitempty"`
-	RequiredApprovingReviewCount   int  `yaml:"requiredApprovingReviewCount,omitempty"`
-	RequiredReviewThreadResolution bool `yaml:"requiredReviewThreadResolution,omitempty"`
-	RequireLastPushApproval        bool `yaml:"requireLastPushApproval,omitempty"`
+	DismissStaleReviewsOnPush             bool     `yaml:"dismissStaleReviewsOnPush,omitempty"`
+	RequireCodeOwnerReview                bool     `yaml:"requireCodeOwnerReview,omitempty"`
+	RequiredApprovingReviewCount          int      `yaml:"requiredApprovingReviewCount,omitempty"`
+	RequiredCodeOwnerApprovingReviewCount int      `yaml:"requiredCodeOwnerApprovingReviewCount,omitempty"` // 0 or 1, implies requireCodeOwnerReview
+	RequiredReviewThreadResolution        bool     `yaml:"requiredReviewThreadResolution,omitempty"`
+	RequireLastPushApproval               bool     `yaml:"requireLastPushApproval,omitempty"`
+	RequiredReviewers                     []string `yaml:"requiredReviewers,omitempty"` // teams that must review the pull requests
+	CodeownersPath                        string   `yaml:"codeownersPath,omitempty"`    // informative, validated against the Github CODEOWNERS locations
 
 	// RequiredStatusChecksParameters
 	RequiredStatusChecks             []string `yaml:"requiredStatusChecks,omitempty"`
 	StrictRequiredStatusChecksPolicy bool     `yaml:"strictRequiredStatusChecksPolicy,omitempty"`
+	// status check context -> Github App integration id expected to provide it
+	RequiredStatusChecksIntegrations map[string]int `yaml:"requiredStatusChecksIntegrations,omitempty"`
 }
 
 func CompareRulesetParameters(ruletype string, left RuleSetParameters, right RuleSetParameters) bool {
@@ -50,6 +62,9 @@ func CompareRulesetParameters(ruletype string, left RuleSetParameters, right Rul
 		if left.RequireLastPushApproval != right.RequireLastPushApproval {
 			return false
 		}
+		if res, _, _ := StringArrayEquivalent(left.RequiredReviewers, right.RequiredReviewers); !res {
+			return false
+		}
 		return true
 	case "required_status_checks":
 		if res, _, _ := StringArrayEquivalent(left.RequiredStatusChecks, right.RequiredStatusChecks); !res {
@@ -58,35 +73,176 @@ func CompareRulesetParameters(ruletype string, left RuleSetParameters, right Rul
 		if left.StrictRequiredStatusChecksPolicy != right.StrictRequiredStatusChecksPolicy {
 			return false
 		}
+		if len(left.RequiredStatusChecksIntegrations) != len(right.RequiredStatusChecksIntegrations) {
+			return false
+		}
+		for check, id := range left.RequiredStatusChecksIntegrations {
+			if rid, ok := right.RequiredStatusChecksIntegrations[check]; !ok || rid != id {
+				return false
+			}
+		}
 		return true
 	}
 	return false
 }
 
+/*
+ * enforcementVariants returns the pairs of rulesets names (sorted) whose
+ * definitions are identical except for their enforcement, i.e. an evaluate
+ * copy left behind after its promotion to active
+ */
+func enforcementVariants(definitions map[string]RuleSetDefinition) [][2]string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variants := [][2]string{}
+	for i, left := range names {
+		for _, right := range names[i+1:] {
+			l := definitions[left]
+			r := definitions[right]
+			if l.Enforcement == r.Enforcement {
+				continue
+			}
+			r.Enforcement = l.Enforcement
+			if CompareRuleSetDefinitions(l, r) {
+				variants = append(variants, [2]string{left, right})
+			}
+		}
+	}
+	return variants
+}
+
+type RuleSetBypassApp struct {
+	AppName       string
+	Mode          string // always, pull_request
+	Justification string `yaml:"justification,omitempty"` // why this app can bypass the ruleset
+}
+
+/*
+ * CompareRuleSetDefinitions compares 2 ruleset definitions: enforcement,
+ * conditions, bypass apps and rules. Lists are compared regardless of their order,
+ * and cosmetic fields (description, justification) are ignored
+ * Unlike CompareRulesetParameters (used against Github), the codeownersPath
+ * is compared: Github has no such parameter, but it is part of the definition
+ */
+func CompareRuleSetDefinitions(left RuleSetDefinition, right RuleSetDefinition) bool {
+	if left.Enforcement != right.Enforcement {
+		return false
+	}
+	if left.target() != right.target() {
+		return false
+	}
+	if res, _, _ := StringArrayEquivalent(left.Conditions.Include, right.Conditions.Include); !res {
+		return false
+	}
+	if res, _, _ := StringArrayEquivalent(left.Conditions.Exclude, right.Conditions.Exclude); !res {
+		return false
+	}
+	if len(left.Conditions.Properties) != len(right.Conditions.Properties) {
+		return false
+	}
+	for key, value := range left.Conditions.Properties {
+		if rvalue, ok := right.Conditions.Properties[key]; !ok || rvalue != value {
+			return false
+		}
+	}
+
+	if len(left.BypassApps) != len(right.BypassApps) {
+		return false
+	}
+	leftBypass := make(map[string]string)
+	for _, ba := range left.BypassApps {
+		leftBypass[ba.AppName] = ba.Mode
+	}
+	for _, ba := range right.BypassApps {
+		if mode, ok := leftBypass[ba.AppName]; !ok || mode != ba.Mode {
+			return false
+		}
+	}
+
+	if len(left.Rules) != len(right.Rules) {
+		return false
+	}
+	leftRules := make(map[string]RuleSetParameters)
+	for _, rule := range left.Rules {
+		leftRules[rule.Ruletype] = rule.Parameters
+	}
+	for _, rule := range right.Rules {
+		parameters, ok := leftRules[rule.Ruletype]
+		if !ok || !CompareRulesetParameters(rule.Ruletype, parameters, rule.Parameters) {
+			return false
+		}
+		if parameters.CodeownersPath != rule.Parameters.CodeownersPath {
+			return false
+		}
+	}
+	return true
+}
+
 type RuleSetDefinition struct {
-	// Target // branch, tag
-	Enforcement string // disabled, active, evaluate
-	BypassApps  []struct {
-		AppName string
-		Mode    string // always, pull_request
-	} `yaml:"bypassapps,omitempty"`
-	Conditions struct {
-		Include []string `yaml:"include,omitempty"` // ~DEFAULT_BRANCH, ~ALL, branch_name, ...
-		Exclude []string `yaml:"exclude,omitempty"` //  branch_name, ...
-	} `yaml:"conditions,omitempty"`
+	Target      string             `yaml:"target,omitempty"` // branch (default), tag
+	Enforcement string             // disabled, active, evaluate
+	Description string             `yaml:"description,omitempty"` // cosmetic, not compared
+	BypassApps  []RuleSetBypassApp `yaml:"bypassapps,omitempty"`
+	Conditions  RuleSetConditions  `yaml:"conditions,omitempty"`
+
+	Rules []RuleSetRule `yaml:"rules"`
+}
+
+type RuleSetConditions struct {
+	Include []string `yaml:"include,omitempty"` // ~DEFAULT_BRANCH, ~ALL, branch_name, ...
+	Exclude []string `yaml:"exclude,omitempty"` //  branch_name, ...
+	// repository custom properties values the repositories must have (global rulesets only)
+	Properties map[string]string `yaml:"properties,omitempty"`
+}
 
-	Rules []struct {
-		Ruletype   string            // required_signatures, pull_request, required_status_checks, creation, update, deletion, non_fast_forward
-		Parameters RuleSetParameters `yaml:"parameters,omitempty"`
-	} `yaml:"rules"`
+type RuleSetRule struct {
+	Ruletype   string            // required_signatures, pull_request, required_status_checks, creation, update, deletion, non_fast_forward
+	Parameters RuleSetParameters `yaml:"parameters,omitempty"`
+}
+
+/*
+ * clone returns a deep copy of the ruleset definition
+ */
+func (d RuleSetDefinition) clone() RuleSetDefinition {
+	c := d
+	c.BypassApps = append([]RuleSetBypassApp(nil), d.BypassApps...)
+	c.Conditions.Include = append([]string(nil), d.Conditions.Include...)
+	c.Conditions.Exclude = append([]string(nil), d.Conditions.Exclude...)
+	if d.Conditions.Properties != nil {
+		c.Conditions.Properties = make(map[string]string, len(d.Conditions.Properties))
+		for k, v := range d.Conditions.Properties {
+			c.Conditions.Properties[k] = v
+		}
+	}
+	c.Rules = make([]RuleSetRule, len(d.Rules))
+	for i, rule := range d.Rules {
+		c.Rules[i] = rule
+		c.Rules[i].Parameters.RequiredStatusChecks = append([]string(nil), rule.Parameters.RequiredStatusChecks...)
+		c.Rules[i].Parameters.RequiredReviewers = append([]string(nil), rule.Parameters.RequiredReviewers...)
+		if rule.Parameters.RequiredStatusChecksIntegrations != nil {
+			c.Rules[i].Parameters.RequiredStatusChecksIntegrations = make(map[string]int, len(rule.Parameters.RequiredStatusChecksIntegrations))
+			for context, id := range rule.Parameters.RequiredStatusChecksIntegrations {
+				c.Rules[i].Parameters.RequiredStatusChecksIntegrations[context] = id
+			}
+		}
+	}
+	if d.Rules == nil {
+		c.Rules = nil
+	}
+	return c
 }
 
 /*
  * Ruleset are applied per repos based on the goliac configuration file (pattern x ruleset name)
  */
 type RuleSet struct {
-	Entity `yaml:",inline"`
-	Spec   RuleSetDefinition `yaml:"spec"`
+	Entity           `yaml:",inline"`
+	Spec             RuleSetDefinition `yaml:"spec"`
+	DeprecatedFields map[string]string `yaml:"-"` // deprecated fields set in the file -> replacement
 }
 
 /*
@@ -94,27 +250,67 @@ type RuleSet struct {
  * The next step is to validate the RuleSet object using the Validate method
  */
 func NewRuleSet(fs billy.Filesystem, filename string) (*RuleSet, error) {
+	return readRuleSet(fs, filename, defaultFieldDeprecations())
+}
+
+// readRuleSet is NewRuleSet with the given deprecated fields
+func readRuleSet(fs billy.Filesystem, filename string, deprecations fieldDeprecations) (*RuleSet, error) {
 	filecontent, err := utils.ReadFile(fs, filename)
 	if err != nil {
 		return nil, err
 	}
 
+	var document yaml.Node
+	err = yaml.Unmarshal(filecontent, &document)
+	if err != nil {
+		return nil, newParseError(filename, err)
+	}
+
 	ruleset := RuleSet{}
-	err = yaml.Unmarshal(filecontent, &ruleset)
+	err = document.Decode(&ruleset)
 	if err != nil {
-		return nil, err
+		return nil, newParseError(filename, err)
+	}
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "spec" {
+				ruleset.DeprecatedFields = findDeprecatedFields(root.Content[i+1], deprecations.ruleset, "spec.", nil)
+			}
+		}
 	}
+	ruleset.Spec.Enforcement, err = NormalizeEnforcement(ruleset.Spec.Enforcement)
+	if err != nil {
+		return nil, fmt.Errorf("%v for ruleset filename %s", err, filename)
+	}
+	ruleset.Spec.normalize()
 
 	return &ruleset, nil
 }
 
+/*
+ * NormalizeEnforcement returns the canonical (Github) enforcement value:
+ * "disabled", "active" or "evaluate". "disable" is accepted as "disabled"
+ */
+func NormalizeEnforcement(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "disable", "disabled":
+		return "disabled", nil
+	case "active":
+		return "active", nil
+	case "evaluate":
+		return "evaluate", nil
+	}
+	return "", fmt.Errorf("invalid enforcement: %q must be 'disabled', 'active' or 'evaluate'", s)
+}
+
 /**
  * ReadRuleSetDirectory reads all the files in the dirname directory and returns
  * - a map of RuleSet objects
  * - a slice of errors that must stop the validation process
  * - a slice of warning that must not stop the validation process
  */
-func ReadRuleSetDirectory(fs billy.Filesystem, dirname string) (map[string]*RuleSet, []error, []Warning) {
+func ReadRuleSetDirectory(fs billy.Filesystem, dirname string, opts ValidationOptions) (map[string]*RuleSet, []error, []Warning) {
 	errors := []error{}
 	warning := []Warning{}
 	rulesets := make(map[string]*RuleSet)
@@ -135,6 +331,9 @@ func ReadRuleSetDirectory(fs billy.Filesystem, dirname string) (map[string]*Rule
 		return rulesets, errors, warning
 	}
 
+	// lowercase ruleset name -> filename, Github ruleset names are case insensitive
+	filenames := make(map[string]string)
+
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
@@ -143,42 +342,99 @@ func ReadRuleSetDirectory(fs billy.Filesystem, dirname string) (map[string]*Rule
 		if e.Name()[0] == '.' {
 			continue
 		}
-		ruleset, err := NewRuleSet(fs, filepath.Join(dirname, e.Name()))
+		filename := filepath.Join(dirname, e.Name())
+		if !isYamlFile(e.Name()) {
+			warning = append(warning, withFile(filename, NewCodedWarning("non-yaml-file", "file %s doesn't have a .yaml extension", e.Name())))
+			continue
+		}
+		if filepath.Ext(e.Name()) == ".yml" {
+			warning = append(warning, ymlExtensionWarning(filename))
+		}
+		ruleset, err := NewRuleSet(fs, filename)
 		if err != nil {
-			errors = append(errors, err)
+			errors = append(errors, withFile(filename, err))
 		} else {
-			err := ruleset.Validate(filepath.Join(dirname, e.Name()))
+			err, warns := ruleset.Validate(filename, opts)
+			warning = append(warning, warningsWithFile(filename, warns)...)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, withFile(filename, err))
+			} else if other, ok := filenames[strings.ToLower(ruleset.Name)]; ok {
+				errors = append(errors, withFile(filename, fmt.Errorf("ruleset %s is defined in %s and %s (ruleset names are case insensitive)", ruleset.Name, other, filename)))
 			} else {
+				filenames[strings.ToLower(ruleset.Name)] = filename
 				rulesets[ruleset.Name] = ruleset
 			}
 
 		}
 	}
+
+	definitions := make(map[string]RuleSetDefinition)
+	for name, ruleset := range rulesets {
+		definitions[name] = ruleset.Spec
+	}
+	for _, variant := range enforcementVariants(definitions) {
+		warning = append(warning, NewCodedWarning("enforcement-variant", "rulesets %s and %s only differ by their enforcement (leftover dry-run copy?) in directory %s", variant[0], variant[1], dirname))
+	}
 	return rulesets, errors, warning
 }
 
-func (r *RuleSet) Validate(filename string) error {
+/*
+ * ValidateRuleSetFile parses and validates a single ruleset file, returning
+ * both parsing and validation errors, and the warnings (i.e. for a
+ * pre-commit hook). The warnings whose code is in opts.ErrorOnWarningCodes
+ * are returned as errors
+ */
+func ValidateRuleSetFile(fs billy.Filesystem, filename string, opts ValidationOptions) ([]error, []Warning) {
+	ruleset, err := NewRuleSet(fs, filename)
+	if err != nil {
+		return []error{withFile(filename, err)}, []Warning{}
+	}
+	err, warns := ruleset.Validate(filename, opts)
+	errors, warnings := PromoteWarnings(warningsWithFile(filename, warns), opts.ErrorOnWarningCodes)
+	if err != nil {
+		errors = append([]error{withFile(filename, err)}, errors...)
+	}
+	return errors, warnings
+}
+
+func (r *RuleSet) Validate(filename string, opts ValidationOptions) (error, []Warning) {
+	warnings := deprecationWarnings(r.DeprecatedFields, filename)
 
 	if r.ApiVersion != "v1" {
-		return fmt.Errorf("invalid apiVersion: %s for ruleset filename %s", r.ApiVersion, filename)
+		return fmt.Errorf("invalid apiVersion: %s for ruleset filename %s", r.ApiVersion, filename), warnings
 	}
 
 	if r.Kind != "Ruleset" {
-		return fmt.Errorf("invalid kind: %s for ruleset filename %s", r.Kind, filename)
+		return fmt.Errorf("invalid kind: %s for ruleset filename %s", r.Kind, filename), warnings
 	}
 
 	if r.Name == "" {
-		return fmt.Errorf("metadata.name is empty for ruleset filename %s", filename)
+		return fmt.Errorf("metadata.name is empty for ruleset filename %s", filename), warnings
+	}
+
+	if err := validateRuleSetName(r.Name, filename); err != nil {
+		return err, warnings
 	}
 
-	filename = filepath.Base(filename)
-	if r.Name != filename[:len(filename)-len(filepath.Ext(filename))] {
-		return fmt.Errorf("invalid metadata.name: %s for ruleset filename %s", r.Name, filename)
+	basename := filepath.Base(filename)
+	if r.Name != basename[:len(basename)-len(filepath.Ext(basename))] {
+		return fmt.Errorf("invalid metadata.name: %s for ruleset filename %s", r.Name, basename), warnings
 	}
 
-	for _, rule := range r.Spec.Rules {
+	err, warns := r.Spec.validateDefinition(r.Name, basename, opts)
+	warnings = append(warnings, warns...)
+	return err, warnings
+}
+
+/*
+ * validateDefinition checks the ruleset definition (rule types, enforcement,
+ * bypass apps, conditions and parameters) of a RuleSet, or of a team policy
+ * ruleset
+ */
+func (d *RuleSetDefinition) validateDefinition(rulesetname string, filename string, opts ValidationOptions) (error, []Warning) {
+	warnings := []Warning{}
+
+	for _, rule := range d.Rules {
 		if rule.Ruletype != "required_signatures" &&
 			rule.Ruletype != "pull_request" &&
 			rule.Ruletype != "required_status_checks" &&
@@ -186,29 +442,553 @@ func (r *RuleSet) Validate(filename string) error {
 			rule.Ruletype != "update" &&
 			rule.Ruletype != "deletion" &&
 			rule.Ruletype != "non_fast_forward" {
-			return fmt.Errorf("invalid rulettype: %s for ruleset filename %s", rule.Ruletype, filename)
+			return fmt.Errorf("invalid rulettype: %s for ruleset filename %s", rule.Ruletype, filename), warnings
 		}
 	}
 
-	if r.Spec.Enforcement != "disable" && r.Spec.Enforcement != "active" && r.Spec.Enforcement != "evaluate" {
-		return fmt.Errorf("invalid enforcement: %s for ruleset filename %s", r.Spec.Enforcement, filename)
+	if d.Enforcement != "disabled" && d.Enforcement != "active" && d.Enforcement != "evaluate" {
+		return fmt.Errorf("invalid enforcement: %s for ruleset filename %s", d.Enforcement, filename), warnings
 	}
 
-	for _, ba := range r.Spec.BypassApps {
+	for _, ba := range d.BypassApps {
 		if ba.Mode != "always" && ba.Mode != "pull_request" {
-			return fmt.Errorf("invalid mode: %s for bypassapp %s in ruleset filename %s", ba.Mode, ba.AppName, filename)
+			return fmt.Errorf("invalid mode: %s for bypassapp %s in ruleset filename %s", ba.Mode, ba.AppName, filename), warnings
 		}
 	}
-	for _, include := range r.Spec.Conditions.Include {
+	for _, include := range d.Conditions.Include {
 		if include[0] == '~' && (include != "~DEFAULT_BRANCH" && include != "~ALL") {
-			return fmt.Errorf("invalid include: %s in ruleset filename %s", include, filename)
+			return fmt.Errorf("invalid include: %s in ruleset filename %s", include, filename), warnings
 		}
 	}
-	for _, exclude := range r.Spec.Conditions.Exclude {
+	for _, exclude := range d.Conditions.Exclude {
 		if exclude[0] == '~' && (exclude != "~DEFAULT_BRANCH" && exclude != "~ALL") {
-			return fmt.Errorf("invalid exclude: %s in ruleset filename %s", exclude, filename)
+			return fmt.Errorf("invalid exclude: %s in ruleset filename %s", exclude, filename), warnings
+		}
+	}
+
+	for key := range d.Conditions.Properties {
+		if opts.RepositoryPropertyKeys != nil && !opts.RepositoryPropertyKeys[key] {
+			return fmt.Errorf("invalid condition: unknown repository property %s in ruleset filename %s", key, filename), warnings
+		}
+	}
+
+	if err := d.validate(rulesetname, filename, opts); err != nil {
+		return err, warnings
+	}
+
+	warnings = append(warnings, d.warnings(rulesetname, filename, opts)...)
+
+	return nil, warnings
+}
+
+// maximum length of a ruleset name accepted by Github
+const RuleSetNameMaxLength = 100
+
+// maximum length of a ruleset description. The description only documents
+// the ruleset in this repository (Github rulesets have no description), the
+// limit keeps it readable
+const RuleSetDescriptionMaxLength = 350
+
+// maximum number of approving reviews Github accepts in a pull_request rule
+const RuleSetMaxApprovingReviewCount = 6
+
+// default maximum number of rules in a ruleset (see ValidationOptions.RuleSetMaxRules)
+const RuleSetDefaultMaxRules = 1000
+
+// locations where Github looks for the CODEOWNERS file
+var CodeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+/*
+ * validateRuleSetName checks the ruleset name against Github ruleset naming rules
+ */
+func validateRuleSetName(name string, filename string) error {
+	if len(name) > RuleSetNameMaxLength {
+		return fmt.Errorf("invalid ruleset name %s: it must not exceed %d characters (check filename %s)", name, RuleSetNameMaxLength, filename)
+	}
+	for _, c := range name {
+		if unicode.IsControl(c) {
+			return fmt.Errorf("invalid ruleset name %q: it must not contain control characters (check filename %s)", name, filename)
+		}
+	}
+	return nil
+}
+
+/*
+ * normalize completes the ruleset definition with the implicit values
+ */
+func (d *RuleSetDefinition) normalize() {
+	for i, rule := range d.Rules {
+		if rule.Parameters.RequiredCodeOwnerApprovingReviewCount > 0 {
+			d.Rules[i].Parameters.RequireCodeOwnerReview = true
 		}
 	}
+}
 
+/*
+ * target returns the ruleset target: "branch" (default) or "tag"
+ */
+func (d *RuleSetDefinition) target() string {
+	if d.Target == "" {
+		return "branch"
+	}
+	return d.Target
+}
+
+// rule types that only make sense on branches
+var branchOnlyRuleTypes = map[string]bool{
+	"pull_request":           true,
+	"required_status_checks": true,
+	"merge_queue":            true,
+}
+
+/*
+ * validate checks a ruleset definition, shared by the (global) rulesets
+ * and the repositories inline rulesets
+ */
+func (d *RuleSetDefinition) validate(rulesetname string, filename string, opts ValidationOptions) error {
+	if len(d.Description) > RuleSetDescriptionMaxLength {
+		return fmt.Errorf("invalid ruleset %s description: it must not exceed %d characters (check filename %s)", rulesetname, RuleSetDescriptionMaxLength, filename)
+	}
+	if len(d.Rules) > opts.ruleSetMaxRules() {
+		return fmt.Errorf("invalid ruleset %s: %d rules defined, it must not exceed %d rules (check filename %s)", rulesetname, len(d.Rules), opts.ruleSetMaxRules(), filename)
+	}
+	if d.target() != "branch" && d.target() != "tag" {
+		return fmt.Errorf("invalid ruleset %s target: %s must be 'branch' or 'tag' (check filename %s)", rulesetname, d.Target, filename)
+	}
+	ruletypes := make(map[string]bool)
+	for _, rule := range d.Rules {
+		if d.target() == "tag" && branchOnlyRuleTypes[rule.Ruletype] {
+			return fmt.Errorf("invalid ruleset %s: ruletype %s cannot be used on a tag target (check filename %s)", rulesetname, rule.Ruletype, filename)
+		}
+		if opts.RuleSetAvailableFeatures != nil && !opts.RuleSetAvailableFeatures[rule.Ruletype] {
+			return fmt.Errorf("invalid ruleset %s: ruletype %s is not available with the organization Github plan (check filename %s)", rulesetname, rule.Ruletype, filename)
+		}
+		if rule.Parameters.StrictRequiredStatusChecksPolicy && len(rule.Parameters.RequiredStatusChecks) == 0 {
+			return fmt.Errorf("invalid ruleset %s: strictRequiredStatusChecksPolicy is set without any requiredStatusChecks (check filename %s)", rulesetname, filename)
+		}
+		checks := make(map[string]bool)
+		for _, check := range rule.Parameters.RequiredStatusChecks {
+			checks[check] = true
+		}
+		for context, id := range rule.Parameters.RequiredStatusChecksIntegrations {
+			if !checks[context] {
+				return fmt.Errorf("invalid ruleset %s: requiredStatusChecksIntegrations references %s which is not a requiredStatusChecks (check filename %s)", rulesetname, context, filename)
+			}
+			if opts.AllowedStatusChecksIntegrations != nil && !opts.AllowedStatusChecksIntegrations[id] {
+				return fmt.Errorf("invalid ruleset %s: status check %s is provided by unknown integration id %d (check filename %s)", rulesetname, context, id, filename)
+			}
+		}
+		if rule.Parameters.CodeownersPath != "" {
+			found := false
+			for _, p := range CodeownersPaths {
+				if rule.Parameters.CodeownersPath == p {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("invalid ruleset %s: codeownersPath %s must be one of %s (check filename %s)", rulesetname, rule.Parameters.CodeownersPath, strings.Join(CodeownersPaths, ", "), filename)
+			}
+		}
+		if rule.Parameters.RequiredApprovingReviewCount < 0 || rule.Parameters.RequiredApprovingReviewCount > RuleSetMaxApprovingReviewCount {
+			return fmt.Errorf("invalid ruleset %s: requiredApprovingReviewCount %d must be between 0 and %d (check filename %s)", rulesetname, rule.Parameters.RequiredApprovingReviewCount, RuleSetMaxApprovingReviewCount, filename)
+		}
+		// Github rulesets only require a single code owner approval (requireCodeOwnerReview)
+		if rule.Parameters.RequiredCodeOwnerApprovingReviewCount < 0 || rule.Parameters.RequiredCodeOwnerApprovingReviewCount > 1 {
+			return fmt.Errorf("invalid ruleset %s: requiredCodeOwnerApprovingReviewCount %d must be 0 or 1, Github doesn't support several code owner approvals (check filename %s)", rulesetname, rule.Parameters.RequiredCodeOwnerApprovingReviewCount, filename)
+		}
+		if ruletypes[rule.Ruletype] {
+			return fmt.Errorf("invalid ruleset %s: ruletype %s defined more than once (check filename %s)", rulesetname, rule.Ruletype, filename)
+		}
+		ruletypes[rule.Ruletype] = true
+	}
 	return nil
 }
+
+/*
+ * validateReviewers checks that the pull_request required reviewers teams exist
+ */
+func (d *RuleSetDefinition) validateReviewers(rulesetname string, teams map[string]*Team) []error {
+	errors := []error{}
+	for _, rule := range d.Rules {
+		if rule.Ruletype != "pull_request" {
+			continue
+		}
+		for _, reviewer := range rule.Parameters.RequiredReviewers {
+			if _, ok := teams[reviewer]; !ok {
+				errors = append(errors, fmt.Errorf("invalid required reviewer: team %s doesn't exist (check ruleset %s)", reviewer, rulesetname))
+			}
+		}
+	}
+	return errors
+}
+
+/*
+ * MarshalRuleSets returns all the rulesets as a multi-document YAML,
+ * sorted by name (i.e. to review all the rulesets at once)
+ */
+func MarshalRuleSets(rulesets map[string]*RuleSet) ([]byte, error) {
+	names := make([]string, 0, len(rulesets))
+	for name := range rulesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, name := range names {
+		if err := encoder.Encode(rulesets[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/*
+ * ValidateRulesetMappings checks the rulesets mappings of the goliac
+ * configuration (pattern x ruleset name): the patterns must compile and the
+ * referenced rulesets must exist
+ */
+func ValidateRulesetMappings(repoconfig *config.RepositoryConfig, rulesets map[string]*RuleSet) []error {
+	errors := []error{}
+	for _, mapping := range repoconfig.Rulesets {
+		if _, err := regexp.Compile(mapping.Pattern); err != nil {
+			errors = append(errors, withFile("goliac.yaml", fmt.Errorf("invalid pattern %s for ruleset %s: %v", mapping.Pattern, mapping.Ruleset, err)))
+		}
+		if _, ok := rulesets[mapping.Ruleset]; !ok {
+			errors = append(errors, withFile("goliac.yaml", fmt.Errorf("ruleset %s referenced by the goliac configuration doesn't exist", mapping.Ruleset)))
+		}
+	}
+	return errors
+}
+
+/*
+ * ReadRulesetMappings reads the rulesets mappings of a goliac configuration
+ * file (goliac.yaml) and returns the repositories patterns per ruleset name,
+ * as expected by UnmatchedRulesetPatterns. The mappings are checked with
+ * ValidateRulesetMappings, an invalid mapping is not returned
+ */
+func ReadRulesetMappings(fs billy.Filesystem, filename string, rulesets map[string]*RuleSet) (map[string][]string, []error) {
+	mappings := make(map[string][]string)
+
+	content, err := utils.ReadFile(fs, filename)
+	if err != nil {
+		return mappings, []error{err}
+	}
+	var repoconfig config.RepositoryConfig
+	if err := yaml.Unmarshal(content, &repoconfig); err != nil {
+		return mappings, []error{newParseError(filename, err)}
+	}
+
+	errors := ValidateRulesetMappings(&repoconfig, rulesets)
+	for _, mapping := range repoconfig.Rulesets {
+		if _, err := regexp.Compile(mapping.Pattern); err != nil {
+			continue
+		}
+		if _, ok := rulesets[mapping.Ruleset]; !ok {
+			continue
+		}
+		mappings[mapping.Ruleset] = append(mappings[mapping.Ruleset], mapping.Pattern)
+	}
+	return mappings, errors
+}
+
+/*
+ * UnmatchedRulesetPatterns returns, per ruleset, the repositories patterns
+ * (regular expressions, as in the goliac configuration) matching no repository.
+ * An invalid regular expression matches nothing
+ */
+func UnmatchedRulesetPatterns(patterns map[string][]string, repos map[string]*Repository) map[string][]string {
+	unmatched := make(map[string][]string)
+	for rulesetname, rulesetPatterns := range patterns {
+		for _, pattern := range rulesetPatterns {
+			match, err := regexp.Compile(pattern)
+			found := false
+			if err == nil {
+				for reponame := range repos {
+					if match.MatchString(reponame) {
+						found = true
+						break
+					}
+				}
+			}
+			if !found {
+				unmatched[rulesetname] = append(unmatched[rulesetname], pattern)
+			}
+		}
+	}
+	return unmatched
+}
+
+/*
+ * SuggestSharedRulesets groups the repositories carrying the same inline
+ * ruleset definition (see CompareRuleSetDefinitions): each group of 2
+ * repositories or more is a candidate for a shared (global) ruleset.
+ * The result is keyed by the first (by repository name) inline ruleset
+ * name, suffixed by a counter on collision, and lists the sorted repositories
+ */
+func SuggestSharedRulesets(repos map[string]*Repository) map[string][]string {
+	type group struct {
+		name       string
+		definition RuleSetDefinition
+		repos      []string
+	}
+	groups := []*group{}
+	for _, reponame := range SortedRepositoryNames(repos) {
+		for _, ruleset := range repos[reponame].Spec.Rulesets {
+			var found *group
+			for _, g := range groups {
+				if CompareRuleSetDefinitions(g.definition, ruleset.RuleSetDefinition) {
+					found = g
+					break
+				}
+			}
+			if found == nil {
+				groups = append(groups, &group{name: ruleset.Name, definition: ruleset.RuleSetDefinition, repos: []string{reponame}})
+			} else if found.repos[len(found.repos)-1] != reponame {
+				found.repos = append(found.repos, reponame)
+			}
+		}
+	}
+
+	suggestions := make(map[string][]string)
+	for _, g := range groups {
+		if len(g.repos) < 2 {
+			continue
+		}
+		name := g.name
+		for i := 2; suggestions[name] != nil; i++ {
+			name = fmt.Sprintf("%s-%d", g.name, i)
+		}
+		suggestions[name] = g.repos
+	}
+	return suggestions
+}
+
+/*
+ * UsedRuleTypes counts the occurrences of each ruletype across the
+ * repositories inline rulesets and the global rulesets
+ */
+func UsedRuleTypes(repos map[string]*Repository, rulesets map[string]*RuleSet) map[string]int {
+	used := make(map[string]int)
+	for _, repo := range repos {
+		for _, ruleset := range repo.Spec.Rulesets {
+			for _, rule := range ruleset.Rules {
+				used[rule.Ruletype]++
+			}
+		}
+	}
+	for _, ruleset := range rulesets {
+		for _, rule := range ruleset.Spec.Rules {
+			used[rule.Ruletype]++
+		}
+	}
+	return used
+}
+
+/*
+ * AppliesTo returns true if the repository custom properties match
+ * all the ruleset properties conditions (if any)
+ */
+func (r *RuleSet) AppliesTo(repo *Repository) bool {
+	for key, value := range r.Spec.Conditions.Properties {
+		if repo == nil || repo.Spec.Properties[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * ValidateForRepo validates the ruleset in the context of the repository
+ * it is applied to: the required reviewers teams must exist
+ */
+func (r *RuleSet) ValidateForRepo(repo *Repository, teams map[string]*Team) []error {
+	errors := []error{}
+	for _, err := range r.Spec.validateReviewers(r.Name, teams) {
+		errors = append(errors, fmt.Errorf("repository %s: %v", repo.Name, err))
+	}
+	return errors
+}
+
+/*
+ * IncompatibleRuleTypes lists the pairs of rule types that don't make sense
+ * together in the same ruleset (with the reason)
+ */
+var IncompatibleRuleTypes = []struct {
+	Left   string
+	Right  string
+	Reason string
+}{
+	{"update", "pull_request", "the update rule blocks all updates, including pull request merges"},
+	{"update", "required_status_checks", "the update rule blocks all updates, status checks will never be used"},
+	{"update", "non_fast_forward", "the update rule already blocks all updates, including force pushes"},
+}
+
+/*
+ * warnings returns the advisory (non blocking) remarks about a ruleset definition
+ */
+func (d *RuleSetDefinition) warnings(rulesetname string, filename string, opts ValidationOptions) []Warning {
+	warnings := []Warning{}
+
+	ruletypes := make(map[string]bool)
+	for _, rule := range d.Rules {
+		ruletypes[rule.Ruletype] = true
+	}
+	for _, incompatible := range IncompatibleRuleTypes {
+		if ruletypes[incompatible.Left] && ruletypes[incompatible.Right] {
+			warnings = append(warnings, NewCodedWarning("incompatible-rules", "ruleset %s combines %s and %s rules: %s (check filename %s)", rulesetname, incompatible.Left, incompatible.Right, incompatible.Reason, filename))
+		}
+	}
+
+	// heuristic: an 'always' bypass on the only rule likely nullifies the ruleset
+	if d.Enforcement == "active" && len(d.Rules) == 1 {
+		for _, app := range d.BypassApps {
+			if app.Mode == "always" {
+				warnings = append(warnings, NewCodedWarning("ineffective-bypass", "ruleset %s has a single rule (%s) that app %s can always bypass: the ruleset may be ineffective (check filename %s)", rulesetname, d.Rules[0].Ruletype, app.AppName, filename))
+				break
+			}
+		}
+	}
+
+	// in evaluate mode nothing is enforced: failing status checks don't block the merges (nor the deploys)
+	if d.Enforcement == "evaluate" && ruletypes["required_status_checks"] {
+		warnings = append(warnings, NewCodedWarning("evaluate-status-checks", "ruleset %s is in evaluate mode: its required_status_checks don't block anything and cannot gate deploys (check filename %s)", rulesetname, filename))
+	}
+
+	for _, exclude := range d.unreachableExcludes() {
+		warnings = append(warnings, NewCodedWarning("useless-exclude", "ruleset %s excludes %s which is never included (check filename %s)", rulesetname, exclude, filename))
+	}
+
+	for _, rule := range d.Rules {
+		if rule.Ruletype == "required_signatures" && d.targetsAllBranches() {
+			warnings = append(warnings, NewCodedWarning("unsigned-history", "ruleset %s requires signatures on ~ALL branches: pushes containing any unsigned commit (including existing history) will be blocked (check filename %s)", rulesetname, filename))
+		}
+		// recommend to pin each status check to its integration
+		for _, check := range rule.Parameters.RequiredStatusChecks {
+			if _, ok := rule.Parameters.RequiredStatusChecksIntegrations[check]; !ok {
+				warnings = append(warnings, NewCodedWarning("bare-status-check", "ruleset %s requires status check %s without its integration: declare it in requiredStatusChecksIntegrations (check filename %s)", rulesetname, check, filename))
+			}
+		}
+	}
+
+	return warnings
+}
+
+/*
+ * unreachableExcludes returns the excludes that cannot match any of the
+ * includes (only when all the includes are concrete branch names or patterns)
+ */
+func (d *RuleSetDefinition) unreachableExcludes() []string {
+	unreachables := []string{}
+	for _, include := range d.Conditions.Include {
+		if strings.HasPrefix(include, "~") {
+			return unreachables
+		}
+	}
+	for _, exclude := range d.Conditions.Exclude {
+		if strings.HasPrefix(exclude, "~") {
+			continue
+		}
+		reachable := false
+		for _, include := range d.Conditions.Include {
+			matchInclude, _ := path.Match(include, exclude)
+			matchExclude, _ := path.Match(exclude, include)
+			if include == exclude || matchInclude || matchExclude {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			unreachables = append(unreachables, exclude)
+		}
+	}
+	return unreachables
+}
+
+/*
+ * matchesBranch returns true if the branch is included (and not excluded)
+ * by the ruleset conditions. ~DEFAULT_BRANCH is resolved to defaultBranch
+ */
+func (d *RuleSetDefinition) matchesBranch(branch string, defaultBranch string) bool {
+	if d.target() != "branch" {
+		return false
+	}
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			switch pattern {
+			case "~ALL":
+				return true
+			case "~DEFAULT_BRANCH":
+				if defaultBranch != "" && branch == defaultBranch {
+					return true
+				}
+			default:
+				if match, _ := path.Match(pattern, branch); match || pattern == branch {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return matches(d.Conditions.Include) && !matches(d.Conditions.Exclude)
+}
+
+/*
+ * includesDefaultBranchTwice returns true if the include list contains both
+ * ~DEFAULT_BRANCH and the literal default branch name
+ */
+func (d *RuleSetDefinition) includesDefaultBranchTwice(defaultBranch string) bool {
+	tilde, literal := false, false
+	for _, include := range d.Conditions.Include {
+		switch include {
+		case "~DEFAULT_BRANCH":
+			tilde = true
+		case defaultBranch:
+			literal = true
+		}
+	}
+	return tilde && literal
+}
+
+func (d *RuleSetDefinition) targetsAllBranches() bool {
+	for _, include := range d.Conditions.Include {
+		if include == "~ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * ValidateBypassJustification is an optional validator that warns when an
+ * active ruleset enforcing pull requests can be bypassed 'always' by an app
+ * without a justification
+ */
+func ValidateBypassJustification(rulesetname string, d *RuleSetDefinition) []Warning {
+	warnings := []Warning{}
+	if d.Enforcement != "active" {
+		return warnings
+	}
+
+	hasPullRequest := false
+	for _, rule := range d.Rules {
+		if rule.Ruletype == "pull_request" {
+			hasPullRequest = true
+		}
+	}
+	if !hasPullRequest {
+		return warnings
+	}
+
+	for _, ba := range d.BypassApps {
+		if ba.Mode == "always" && strings.TrimSpace(ba.Justification) == "" {
+			warnings = append(warnings, NewCodedWarning("unjustified-bypass", "ruleset %s: bypassapp %s can always bypass the pull_request rule without justification", rulesetname, ba.AppName))
+		}
+	}
+	return warnings
+}
@@ -1,12 +1,16 @@
 package entity
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func fixtureCreateRuleSet(t *testing.T, fs billy.Filesystem) {
@@ -62,13 +66,722 @@ func TestRuleset(t *testing.T) {
 		fs := memfs.New()
 		fixtureCreateRuleSet(t, fs)
 
-		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets")
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
-		assert.Equal(t, len(warns), 0)
+		// ruleset2 required status checks are in evaluate mode, and not pinned to their integration
+		assert.Equal(t, 3, len(warns))
+		assert.Contains(t, warns[0].Error(), "ruleset ruleset2 is in evaluate mode: its required_status_checks don't block anything")
+		assert.Contains(t, warns[1].Error(), "ruleset ruleset2 requires status check circleCI check without its integration")
 		assert.NotNil(t, rulesets)
 		assert.Equal(t, 2, len(rulesets))
 
 	})
+
+	t.Run("happy path: deprecated field", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		deprecations := fieldDeprecations{ruleset: map[string]string{"bypassapps": "bypassActors"}}
+		ruleset, err := readRuleSet(fs, "rulesets/ruleset1.yaml", deprecations)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"spec.bypassapps": "bypassActors"}, ruleset.DeprecatedFields)
+
+		err, warns := ruleset.Validate("rulesets/ruleset1.yaml", ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "field spec.bypassapps is deprecated, use bypassActors instead")
+	})
+
+	t.Run("not happy path: duplicated ruletype", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/duplicated.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: duplicated
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: deletion
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(rulesets))
+	})
+
+	t.Run("not happy path: too many rules", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/large.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: large
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: deletion
+    - ruletype: non_fast_forward
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+
+		rulesets, errs, _ = ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{RuleSetMaxRules: 1})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "it must not exceed 1 rules")
+		assert.Equal(t, 0, len(rulesets))
+	})
+
+	t.Run("not happy path: strict status checks policy without checks", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/strict.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: strict
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: required_status_checks
+      parameters:
+        strictRequiredStatusChecksPolicy: true
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(rulesets))
+	})
+
+	t.Run("not happy path: ruleset names differing only by case", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		for _, name := range []string{"Security", "security"} {
+			err := utils.WriteFile(fs, "rulesets/"+name+".yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: `+name+`
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: deletion
+`), 0644)
+			assert.Nil(t, err)
+		}
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+	})
+
+	t.Run("happy path: evaluate and active copies are a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+		err := utils.WriteFile(fs, "rulesets/ruleset1-active.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1-active
+spec:
+  enforcement: active
+  bypassapps:
+    - appname: goliac-project-app
+      mode: always
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+
+  rules:
+    - ruletype: pull_request
+      parameters:
+        requiredApprovingReviewCount: 1
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		// the active copy can also be always bypassed on its only rule, and ruleset2 is in evaluate mode
+		// with 2 status checks not pinned to their integration
+		assert.Equal(t, 5, len(warns))
+		assert.Equal(t, 3, len(rulesets))
+	})
+
+	t.Run("happy path: unjustified always bypass on the only rule is a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/bypassed.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: bypassed
+spec:
+  enforcement: active
+  bypassapps:
+    - appname: some-app
+      mode: always
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+
+		// the warning is coded, so it can be promoted to an error
+		promoted, _ := PromoteWarnings(warns, []string{"ineffective-bypass"})
+		assert.Equal(t, 1, len(promoted))
+	})
+
+	t.Run("not happy path: too many approving reviews", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/reviews.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: reviews
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: pull_request
+      parameters:
+        requiredApprovingReviewCount: 7
+`), 0644)
+		assert.Nil(t, err)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/reviews.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "reviews")
+	})
+
+	t.Run("not happy path: description too long", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/described.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: described
+spec:
+  enforcement: active
+  description: `+strings.Repeat("a", RuleSetDescriptionMaxLength+1)+`
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/described.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "description")
+
+		err = utils.WriteFile(fs, "rulesets/described.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: described
+spec:
+  enforcement: active
+  description: `+strings.Repeat("a", RuleSetDescriptionMaxLength)+`
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+		errs, _ = ValidateRuleSetFile(fs, "rulesets/described.yaml", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+	})
+
+	t.Run("not happy path: several code owner approvals", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/codeowners.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: codeowners
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: pull_request
+      parameters:
+        requiredCodeOwnerApprovingReviewCount: 3
+`), 0644)
+		assert.Nil(t, err)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/codeowners.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "requiredCodeOwnerApprovingReviewCount 3 must be 0 or 1")
+	})
+
+	t.Run("not happy path: invalid codeowners path", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/codeowners.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: codeowners
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: pull_request
+      parameters:
+        requireCodeOwnerReview: true
+        codeownersPath: src/CODEOWNERS
+`), 0644)
+		assert.Nil(t, err)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/codeowners.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("not happy path: status check from an unknown integration", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/checks.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: checks
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: required_status_checks
+      parameters:
+        requiredStatusChecks:
+        - circleCI check
+        requiredStatusChecksIntegrations:
+          circleCI check: 1234
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{AllowedStatusChecksIntegrations: map[int]bool{1234: true}})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 1, len(rulesets))
+
+		rulesets, errs, _ = ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{AllowedStatusChecksIntegrations: map[int]bool{5678: true}})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(rulesets))
+	})
+
+	t.Run("not happy path: rule type not available with the Github plan", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		opts := ValidationOptions{RuleSetAvailableFeatures: map[string]bool{"pull_request": true}}
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", opts)
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "ruletype required_status_checks is not available")
+		assert.Equal(t, 1, len(rulesets))
+
+		opts.RuleSetAvailableFeatures["required_status_checks"] = true
+		rulesets, errs, _ = ReadRuleSetDirectory(fs, "rulesets", opts)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 2, len(rulesets))
+	})
+
+	t.Run("happy path: status checks without integration are a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{AllowedStatusChecksIntegrations: map[int]bool{1234: true}})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 3, len(warns))
+		assert.Equal(t, 2, len(rulesets))
+	})
+
+	t.Run("happy path: incompatible rule types are a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/frozen.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: frozen
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+  rules:
+    - ruletype: update
+    - ruletype: pull_request
+      parameters:
+        requiredApprovingReviewCount: 1
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "ruleset frozen combines update and pull_request rules")
+		assert.Equal(t, 1, len(rulesets))
+	})
+
+	t.Run("happy path: excludes never included are a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/releases.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: releases
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "release/*"
+    exclude:
+    - "release/legacy"
+    - "hotfix/*"
+  rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "ruleset releases excludes hotfix/* which is never included")
+		assert.Equal(t, 1, len(rulesets))
+	})
+
+	t.Run("happy path: required signatures on all branches is a warning", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/signed.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: signed
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~ALL"
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(rulesets))
+	})
+}
+
+func TestValidateRuleSetFile(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/ruleset1.yaml", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+	})
+	t.Run("not happy path: name not matching the filename", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+		err := fs.Rename("rulesets/ruleset1.yaml", "rulesets/other.yaml")
+		assert.Nil(t, err)
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/other.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+	t.Run("not happy path: missing file", func(t *testing.T) {
+		fs := memfs.New()
+
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/missing.yaml", ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+	t.Run("happy path: warnings are returned, or promoted to errors", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		// ruleset2 required status checks are in evaluate mode, and not pinned to their integration
+		errs, warns := ValidateRuleSetFile(fs, "rulesets/ruleset2.yaml", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 3, len(warns))
+
+		errs, warns = ValidateRuleSetFile(fs, "rulesets/ruleset2.yaml", ValidationOptions{ErrorOnWarningCodes: []string{"evaluate-status-checks"}})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "is in evaluate mode")
+		assert.Equal(t, 2, len(warns))
+	})
+}
+
+func TestRuleSetPropertiesConditions(t *testing.T) {
+	t.Run("happy path: ruleset applies to repositories matching the properties", func(t *testing.T) {
+		rs := &RuleSet{}
+		rs.Spec.Conditions.Properties = map[string]string{"tier": "1"}
+
+		repo1 := &Repository{}
+		repo1.Spec.Properties = map[string]string{"tier": "1"}
+		repo2 := &Repository{}
+		repo2.Spec.Properties = map[string]string{"tier": "2"}
+
+		assert.True(t, rs.AppliesTo(repo1))
+		assert.False(t, rs.AppliesTo(repo2))
+		assert.False(t, rs.AppliesTo(&Repository{}))
+		assert.True(t, (&RuleSet{}).AppliesTo(repo2))
+	})
+	t.Run("not happy path: unknown property key", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/tier1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: tier1
+spec:
+  enforcement: active
+  conditions:
+    include: 
+    - "~DEFAULT_BRANCH"
+    properties:
+      tier: "1"
+  rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		opts := ValidationOptions{RepositoryPropertyKeys: map[string]bool{"team": true}}
+		errs, _ := ValidateRuleSetFile(fs, "rulesets/tier1.yaml", opts)
+		assert.Equal(t, 1, len(errs))
+
+		opts.RepositoryPropertyKeys["tier"] = true
+		errs, _ = ValidateRuleSetFile(fs, "rulesets/tier1.yaml", opts)
+		assert.Equal(t, 0, len(errs))
+	})
+}
+
+func TestUsedRuleTypes(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		repo := &Repository{}
+		repo.Spec.Rulesets = []RepositoryRuleSet{
+			{RuleSetDefinition: RuleSetDefinition{Rules: []RuleSetRule{{Ruletype: "pull_request"}, {Ruletype: "deletion"}}}},
+		}
+
+		used := UsedRuleTypes(map[string]*Repository{"repo1": repo}, rulesets)
+		assert.Equal(t, map[string]int{"pull_request": 2, "required_status_checks": 1, "deletion": 1}, used)
+	})
+}
+
+func TestNormalizeEnforcement(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		for raw, expected := range map[string]string{"disable": "disabled", "disabled": "disabled", "Active": "active", "evaluate": "evaluate"} {
+			enforcement, err := NormalizeEnforcement(raw)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, enforcement)
+		}
+	})
+	t.Run("not happy path: unknown value", func(t *testing.T) {
+		_, err := NormalizeEnforcement("enabled")
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "enabled")
+	})
+}
+
+func TestMarshalRuleSets(t *testing.T) {
+	t.Run("happy path: round trip", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		content, err := MarshalRuleSets(rulesets)
+		assert.Nil(t, err)
+		assert.True(t, strings.Index(string(content), "name: ruleset1") < strings.Index(string(content), "name: ruleset2"))
+
+		documents := strings.Split(string(content), "---\n")
+		assert.Equal(t, 2, len(documents))
+		for i, document := range documents {
+			filename := fmt.Sprintf("export/ruleset%d.yaml", i+1)
+			err := utils.WriteFile(fs, filename, []byte(document), 0644)
+			assert.Nil(t, err)
+			ruleset, err := NewRuleSet(fs, filename)
+			assert.Nil(t, err)
+			assert.Equal(t, rulesets[ruleset.Name], ruleset)
+		}
+	})
+}
+
+func TestValidateRulesetMappings(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		var repoconfig config.RepositoryConfig
+		err := yaml.Unmarshal([]byte(`
+rulesets:
+- pattern: ".*"
+  ruleset: ruleset1
+- pattern: "^service-"
+  ruleset: ruleset2
+`), &repoconfig)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(ValidateRulesetMappings(&repoconfig, rulesets)))
+	})
+
+	t.Run("not happy path: invalid pattern and unknown ruleset", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		var repoconfig config.RepositoryConfig
+		err := yaml.Unmarshal([]byte(`
+rulesets:
+- pattern: "(["
+  ruleset: ruleset1
+- pattern: ".*"
+  ruleset: ruleset3
+`), &repoconfig)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		errs = ValidateRulesetMappings(&repoconfig, rulesets)
+		assert.Equal(t, 2, len(errs))
+		assert.Contains(t, errs[0].Error(), "([")
+		assert.Contains(t, errs[1].Error(), "ruleset3")
+		assert.Equal(t, "goliac.yaml", validationFile(errs[1]))
+	})
+}
+
+func TestReadRulesetMappings(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+rulesets:
+- pattern: ".*"
+  ruleset: ruleset1
+- pattern: "^service-"
+  ruleset: ruleset2
+- pattern: "^lib-"
+  ruleset: ruleset2
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		mappings, errs := ReadRulesetMappings(fs, "goliac.yaml", rulesets)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, map[string][]string{"ruleset1": {".*"}, "ruleset2": {"^service-", "^lib-"}}, mappings)
+	})
+
+	t.Run("not happy path: invalid mappings are not returned", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+rulesets:
+- pattern: "(["
+  ruleset: ruleset1
+- pattern: ".*"
+  ruleset: ruleset3
+- pattern: ".*"
+  ruleset: ruleset2
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		mappings, errs := ReadRulesetMappings(fs, "goliac.yaml", rulesets)
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, map[string][]string{"ruleset2": {".*"}}, mappings)
+	})
+
+	t.Run("not happy path: missing file", func(t *testing.T) {
+		fs := memfs.New()
+		mappings, errs := ReadRulesetMappings(fs, "goliac.yaml", map[string]*RuleSet{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(mappings))
+	})
+}
+
+func TestSuggestSharedRulesets(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		protection := RuleSetDefinition{
+			Enforcement: "active",
+			Conditions:  RuleSetConditions{Include: []string{"~DEFAULT_BRANCH"}},
+			Rules:       []RuleSetRule{{Ruletype: "deletion"}},
+		}
+		release := RuleSetDefinition{
+			Enforcement: "active",
+			Conditions:  RuleSetConditions{Include: []string{"release/*"}},
+			Rules:       []RuleSetRule{{Ruletype: "deletion"}},
+		}
+		repos := map[string]*Repository{}
+		for _, name := range []string{"repo1", "repo2", "repo3"} {
+			repo := &Repository{}
+			repo.Name = name
+			repos[name] = repo
+		}
+		repos["repo1"].Spec.Rulesets = []RepositoryRuleSet{{Name: "main", RuleSetDefinition: protection}, {Name: "release", RuleSetDefinition: release}}
+		repos["repo2"].Spec.Rulesets = []RepositoryRuleSet{{Name: "default", RuleSetDefinition: protection}}
+		repos["repo3"].Spec.Rulesets = []RepositoryRuleSet{{Name: "main", RuleSetDefinition: protection}}
+
+		suggestions := SuggestSharedRulesets(repos)
+		assert.Equal(t, map[string][]string{"main": {"repo1", "repo2", "repo3"}}, suggestions)
+	})
+}
+
+func TestUnmatchedRulesetPatterns(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		repos := map[string]*Repository{
+			"service-a": {},
+			"lib-b":     {},
+		}
+		patterns := map[string][]string{
+			"default":  {".*"},
+			"services": {"^service-", "^servcie-"},
+			"broken":   {"(["},
+		}
+
+		unmatched := UnmatchedRulesetPatterns(patterns, repos)
+		assert.Equal(t, map[string][]string{
+			"services": {"^servcie-"},
+			"broken":   {"(["},
+		}, unmatched)
+	})
 }
 
 func TestRulesetParametersComparison(t *testing.T) {
@@ -79,9 +792,9 @@ func TestRulesetParametersComparison(t *testing.T) {
 		fs := memfs.New()
 		fixtureCreateRuleSet(t, fs)
 
-		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets")
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
-		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, 3, len(warns))
 		assert.NotNil(t, rulesets)
 
 		res := CompareRulesetParameters(rulesets["ruleset1"].Spec.Rules[0].Ruletype, rulesets["ruleset1"].Spec.Rules[0].Parameters, rulesets["ruleset2"].Spec.Rules[0].Parameters)
@@ -93,4 +806,104 @@ func TestRulesetParametersComparison(t *testing.T) {
 		res = CompareRulesetParameters(rulesets["ruleset2"].Spec.Rules[0].Ruletype, rulesets["ruleset2"].Spec.Rules[0].Parameters, rulesets["ruleset2"].Spec.Rules[0].Parameters)
 		assert.True(t, res)
 	})
+	t.Run("happy path: code owner approving review count is not sent to Github", func(t *testing.T) {
+		left := RuleSetParameters{RequireCodeOwnerReview: true, RequiredCodeOwnerApprovingReviewCount: 1}
+		right := RuleSetParameters{RequireCodeOwnerReview: true}
+		assert.True(t, CompareRulesetParameters("pull_request", left, right))
+	})
+	t.Run("not happy path: different required reviewers", func(t *testing.T) {
+		left := RuleSetParameters{RequiredReviewers: []string{"security", "team1"}}
+		right := RuleSetParameters{RequiredReviewers: []string{"team1"}}
+		assert.False(t, CompareRulesetParameters("pull_request", left, right))
+
+		right.RequiredReviewers = []string{"team1", "security"}
+		assert.True(t, CompareRulesetParameters("pull_request", left, right))
+	})
+	t.Run("not happy path: status checks pinned to different integrations", func(t *testing.T) {
+		left := RuleSetParameters{RequiredStatusChecks: []string{"circleCI check"}, RequiredStatusChecksIntegrations: map[string]int{"circleCI check": 1234}}
+		right := RuleSetParameters{RequiredStatusChecks: []string{"circleCI check"}}
+		assert.False(t, CompareRulesetParameters("required_status_checks", left, right))
+
+		right.RequiredStatusChecksIntegrations = map[string]int{"circleCI check": 5678}
+		assert.False(t, CompareRulesetParameters("required_status_checks", left, right))
+
+		right.RequiredStatusChecksIntegrations = map[string]int{"circleCI check": 1234}
+		assert.True(t, CompareRulesetParameters("required_status_checks", left, right))
+	})
+}
+
+func TestRuleSetValidateForRepo(t *testing.T) {
+	ruleset := &RuleSet{}
+	ruleset.Name = "reviews"
+	ruleset.Spec.Rules = append(ruleset.Spec.Rules, struct {
+		Ruletype   string
+		Parameters RuleSetParameters `yaml:"parameters,omitempty"`
+	}{
+		"pull_request", RuleSetParameters{RequiredReviewers: []string{"security"}},
+	})
+	repo := &Repository{}
+	repo.Name = "repo1"
+
+	t.Run("happy path: the required reviewers teams exist", func(t *testing.T) {
+		errs := ruleset.ValidateForRepo(repo, map[string]*Team{"security": {}})
+		assert.Equal(t, 0, len(errs))
+	})
+	t.Run("not happy path: unknown required reviewers team", func(t *testing.T) {
+		errs := ruleset.ValidateForRepo(repo, map[string]*Team{"team1": {}})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "repository repo1")
+	})
+}
+
+func TestValidateBypassJustification(t *testing.T) {
+	t.Run("not happy path: always bypass without justification", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		ruleset1 := rulesets["ruleset1"]
+		ruleset1.Spec.Enforcement = "active"
+		assert.Equal(t, 1, len(ValidateBypassJustification(ruleset1.Name, &ruleset1.Spec)))
+
+		ruleset1.Spec.BypassApps[0].Justification = "release automation"
+		assert.Equal(t, 0, len(ValidateBypassJustification(ruleset1.Name, &ruleset1.Spec)))
+	})
+}
+
+func TestCompareRuleSetDefinitions(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		assert.True(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, rulesets["ruleset1"].Spec))
+		assert.False(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, rulesets["ruleset2"].Spec))
+
+		other := rulesets["ruleset1"].Spec.clone()
+		other.Description = "cosmetic"
+		assert.True(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, other))
+
+		other.Enforcement = "active"
+		assert.False(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, other))
+	})
+
+	t.Run("happy path: properties conditions and codeowners path are compared", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateRuleSet(t, fs)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets", ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		other := rulesets["ruleset1"].Spec.clone()
+		other.Conditions.Properties = map[string]string{"tier": "1"}
+		assert.False(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, other))
+
+		other = rulesets["ruleset1"].Spec.clone()
+		other.Rules[0].Parameters.CodeownersPath = ".github/CODEOWNERS"
+		assert.False(t, CompareRuleSetDefinitions(rulesets["ruleset1"].Spec, other))
+	})
 }
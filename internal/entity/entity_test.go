@@ -1,11 +1,16 @@
 package entity
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestEntity(t *testing.T) {
@@ -48,3 +53,186 @@ name:
 		assert.NotNil(t, err)
 	})
 }
+
+func TestPromoteWarnings(t *testing.T) {
+	t.Run("happy path: only the selected codes are promoted", func(t *testing.T) {
+		warnings := []Warning{
+			NewCodedWarning("misplaced-repo", "repository %s is misplaced", "repo1"),
+			NewCodedWarning("redundant-grant", "team %s is redundant", "team1"),
+			fmt.Errorf("not coded"),
+		}
+
+		errs, warns := PromoteWarnings(warnings, []string{"misplaced-repo"})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "repository repo1 is misplaced", errs[0].Error())
+		assert.Equal(t, 2, len(warns))
+
+		errs, warns = PromoteWarnings(warnings, nil)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 3, len(warns))
+	})
+}
+
+func TestParseError(t *testing.T) {
+	t.Run("not happy path: yaml syntax error", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: [repo1
+`), 0644)
+		assert.Nil(t, err)
+
+		_, err = NewRepository(fs, "teams/team1/repo1.yaml")
+		var pe *ParseError
+		assert.True(t, errors.As(err, &pe))
+		assert.Equal(t, "syntax", pe.Kind)
+		assert.Equal(t, "teams/team1/repo1.yaml", pe.File)
+		assert.NotEqual(t, 0, pe.Line)
+	})
+	t.Run("not happy path: yaml type error", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  rules: not-a-list
+`), 0644)
+		assert.Nil(t, err)
+
+		_, err = NewRuleSet(fs, "rulesets/ruleset1.yaml")
+		var pe *ParseError
+		assert.True(t, errors.As(err, &pe))
+		assert.Equal(t, "type", pe.Kind)
+		assert.Equal(t, 6, pe.Line)
+	})
+}
+
+func TestFormatValidationResults(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		errs := []error{
+			withFile("teams/team1/repo1.yaml", fmt.Errorf("invalid writer: team3 doesn't exist (check repository filename repo1.yaml)")),
+			&ParseError{File: "rulesets/ruleset1.yaml", Line: 6, Kind: "type", Err: fmt.Errorf("cannot unmarshal")},
+		}
+		warns := []Warning{
+			withFile("teams/team1/team.yaml", fmt.Errorf("not enough owners for team filename teams/team1")),
+			withFile("teams/team1/repo1.yaml", NewCodedWarning("no-description", "repository repo1 has no description")),
+			fmt.Errorf("no default ruleset"),
+		}
+
+		report := FormatValidationResults(errs, warns, false)
+		assert.Equal(t, `rulesets/ruleset1.yaml
+  error: `+errs[1].Error()+`
+teams/team1/repo1.yaml
+  error: invalid writer: team3 doesn't exist (check repository filename repo1.yaml)
+  warning: repository repo1 has no description
+teams/team1/team.yaml
+  warning: not enough owners for team filename teams/team1
+(no file)
+  warning: no default ruleset
+2 error(s), 3 warning(s)
+`, report)
+	})
+
+	t.Run("happy path: same filename in different directories", func(t *testing.T) {
+		errs := []error{
+			withFile("teams/team1/repo1.yaml", fmt.Errorf("first")),
+			withFile("archived/repo1.yaml", fmt.Errorf("second")),
+		}
+
+		report := FormatValidationResults(errs, nil, false)
+		assert.Equal(t, `archived/repo1.yaml
+  error: second
+teams/team1/repo1.yaml
+  error: first
+2 error(s), 0 warning(s)
+`, report)
+	})
+
+	t.Run("happy path: the file is not scraped from the message", func(t *testing.T) {
+		report := FormatValidationResults([]error{fmt.Errorf("invalid name (check repository filename repo1.yaml)")}, nil, false)
+		assert.True(t, strings.HasPrefix(report, "(no file)\n"))
+	})
+
+	t.Run("happy path: the file is kept through the warning code", func(t *testing.T) {
+		warn := withFile("teams/team1/repo1.yml", NewCodedWarning("yml-extension", "boom"))
+		promoted, remaining := PromoteWarnings([]Warning{warn}, []string{"yml-extension"})
+		assert.Equal(t, 1, len(promoted))
+		assert.Equal(t, 0, len(remaining))
+		assert.Equal(t, "teams/team1/repo1.yml", validationFile(promoted[0]))
+	})
+
+	t.Run("happy path: colorized", func(t *testing.T) {
+		report := FormatValidationResults([]error{fmt.Errorf("boom")}, nil, true)
+		assert.True(t, strings.Contains(report, "\033[31merror\033[0m: boom"))
+	})
+}
+
+func TestNewValidationOptions(t *testing.T) {
+	t.Run("happy path: default options", func(t *testing.T) {
+		opts, errs := NewValidationOptions(&config.RepositoryConfig{})
+		assert.Equal(t, 0, len(errs))
+		assert.Nil(t, opts.RuleSetAvailableFeatures)
+		assert.Nil(t, opts.RepositoryNamePattern)
+		assert.Nil(t, opts.RepositoryNameFromFile)
+		assert.Equal(t, "teams", opts.repositoryReservedName("Teams"))
+	})
+
+	t.Run("happy path: options set in goliac.yaml", func(t *testing.T) {
+		var repoconfig config.RepositoryConfig
+		err := yaml.Unmarshal([]byte(`
+validation:
+  error_on_warnings:
+  - not-enough-owners
+  repository_max_team_depth: 2
+  repository_name_pattern: ^[a-z]+$
+  repository_name_from_filename: ^(.+)\.repo\.yaml$
+  repository_reserved_names:
+  - legacy
+  repository_property_keys:
+  - cost-center
+  ruleset_available_features:
+  - pull_request
+  ruleset_max_rules: 10
+  allowed_status_checks_integrations:
+  - 15368
+  owner_required_reviewer: true
+  app_visibility_scopes:
+    internal-bot: internal
+  variables:
+    LANGUAGE: go
+  strict_variables: true
+`), &repoconfig)
+		assert.Nil(t, err)
+
+		opts, errs := NewValidationOptions(&repoconfig)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, []string{"not-enough-owners"}, opts.ErrorOnWarningCodes)
+		assert.Equal(t, 2, opts.RepositoryMaxTeamDepth)
+		assert.True(t, opts.RepositoryNamePattern.MatchString("repo"))
+		assert.Equal(t, "repo1", repositoryNameFromFile("teams/team1/repo1.repo.yaml", opts))
+		assert.Equal(t, "repo2", repositoryNameFromFile("teams/team1/repo2.yaml", opts))
+		assert.Equal(t, "legacy", opts.repositoryReservedName("legacy"))
+		assert.Equal(t, "", opts.repositoryReservedName("teams"))
+		assert.Equal(t, map[string]bool{"cost-center": true}, opts.RepositoryPropertyKeys)
+		assert.Equal(t, map[string]bool{"pull_request": true}, opts.RuleSetAvailableFeatures)
+		assert.Equal(t, 10, opts.ruleSetMaxRules())
+		assert.Equal(t, map[int]bool{15368: true}, opts.AllowedStatusChecksIntegrations)
+		assert.True(t, opts.OwnerRequiredReviewer)
+		assert.Equal(t, "internal", opts.AppVisibilityScopes["internal-bot"])
+		assert.Equal(t, map[string]string{"LANGUAGE": "go", "TEAM": "team1"}, opts.repositoryVariables("team1"))
+		assert.True(t, opts.StrictVariables)
+	})
+
+	t.Run("not happy path: invalid patterns", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.Validation.RepositoryNamePattern = "(["
+		repoconfig.Validation.RepositoryNameFromFilename = "^index.yaml$"
+
+		_, errs := NewValidationOptions(&repoconfig)
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, "goliac.yaml", validationFile(errs[0]))
+		assert.Contains(t, errs[1].Error(), "must capture the repository name")
+	})
+}
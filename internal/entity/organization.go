@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+/*
+ * Organization is the in-memory representation of a loaded goliac organization
+ */
+type Organization struct {
+	Users         map[string]*User
+	ExternalUsers map[string]*User
+	Teams         map[string]*Team
+	Repositories  map[string]*Repository
+	RuleSets      map[string]*RuleSet
+}
+
+/*
+ * OrgValidator is a validation run once all the entities are loaded,
+ * with the full organization view (i.e. to implement global policies)
+ */
+type OrgValidator interface {
+	Validate(org *Organization) []error
+}
+
+/*
+ * ReadOrganizationUsers reads the organization users (from the
+ * <orgDirectory>/users/protected and <orgDirectory>/users/org directories)
+ * and the external users (from the <orgDirectory>/users/external directory)
+ */
+func ReadOrganizationUsers(fs billy.Filesystem) (map[string]*User, map[string]*User, []error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+	users := map[string]*User{}
+
+	for _, dirname := range []string{filepath.Join("users", "protected"), filepath.Join("users", "org")} {
+		dirUsers, errs, warns := ReadUserDirectory(fs, dirname)
+		errors = append(errors, errs...)
+		warnings = append(warnings, warns...)
+		for k, v := range dirUsers {
+			users[k] = v
+		}
+	}
+
+	externalUsers, errs, warns := ReadExternalUserDirectory(fs, filepath.Join("users", "external"))
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+
+	return users, externalUsers, errors, warnings
+}
+
+/**
+ * LoadOrganization reads all the organization files (users, teams,
+ * repositories and rulesets), then runs the validators, and returns
+ * - the Organization
+ * - a slice of errors that must stop the validation process
+ * - a slice of warning that must not stop the validation process
+ */
+func LoadOrganization(fs billy.Filesystem, validators []OrgValidator, opts ValidationOptions) (*Organization, []error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+	org := &Organization{
+		Users:         map[string]*User{},
+		ExternalUsers: map[string]*User{},
+		Teams:         map[string]*Team{},
+		Repositories:  map[string]*Repository{},
+		RuleSets:      map[string]*RuleSet{},
+	}
+
+	users, externalUsers, errs, warns := ReadOrganizationUsers(fs)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	org.Users = users
+	org.ExternalUsers = externalUsers
+
+	if len(errors) > 0 {
+		return org, errors, warnings
+	}
+
+	// Parse all the teams in the <orgDirectory>/teams directory
+	teams, errs, warns := ReadTeamDirectory(fs, "teams", org.Users, opts)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	org.Teams = teams
+
+	// Parse all repositories in the <orgDirectory>/teams/<teamname> directories
+	repos, errs, warns := ReadRepositories(fs, "archived", "teams", org.Teams, org.ExternalUsers, opts)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	org.Repositories = repos
+
+	rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets", opts)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	org.RuleSets = rulesets
+
+	for _, validator := range validators {
+		errors = append(errors, validator.Validate(org)...)
+	}
+
+	promoted, warnings := PromoteWarnings(warnings, opts.ErrorOnWarningCodes)
+	errors = append(errors, promoted...)
+
+	return org, errors, warnings
+}
+
+type OrgSummary struct {
+	Repositories         int // all repositories, including archived ones
+	ArchivedRepositories int
+	PublicRepositories   int // non archived public repositories
+	PrivateRepositories  int // non archived private repositories
+	RuleSets             int
+	ExternalUsers        int
+}
+
+/*
+ * Summary returns the counts of the loaded organization entities
+ */
+func (o *Organization) Summary() OrgSummary {
+	summary := OrgSummary{
+		Repositories:  len(o.Repositories),
+		RuleSets:      len(o.RuleSets),
+		ExternalUsers: len(o.ExternalUsers),
+	}
+	for _, repo := range o.Repositories {
+		switch {
+		case repo.Archived:
+			summary.ArchivedRepositories++
+		case repo.Spec.IsPublic:
+			summary.PublicRepositories++
+		default:
+			summary.PrivateRepositories++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationSummary(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		public := &Repository{}
+		public.Spec.IsPublic = true
+		org := &Organization{
+			ExternalUsers: map[string]*User{"external1": {}},
+			Repositories: map[string]*Repository{
+				"repo1":    {},
+				"archived": {Archived: true},
+				"public":   public,
+			},
+			RuleSets: map[string]*RuleSet{},
+		}
+
+		summary := org.Summary()
+		assert.Equal(t, 3, summary.Repositories)
+		assert.Equal(t, 1, summary.ArchivedRepositories)
+		assert.Equal(t, 1, summary.PublicRepositories)
+		assert.Equal(t, 1, summary.PrivateRepositories)
+		assert.Equal(t, 0, summary.RuleSets)
+		assert.Equal(t, 1, summary.ExternalUsers)
+	})
+}
+
+type orgValidatorMock struct {
+	called bool
+}
+
+func (v *orgValidatorMock) Validate(org *Organization) []error {
+	v.called = true
+	if len(org.Repositories) != 1 {
+		return []error{fmt.Errorf("expected 1 repository, got %d", len(org.Repositories))}
+	}
+	return []error{}
+}
+
+func TestLoadOrganization(t *testing.T) {
+	t.Run("happy path: validators are run with the loaded organization", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+		fs.MkdirAll("users/org", 0755)
+		fs.Rename("users/user1.yaml", "users/org/user1.yaml")
+		fs.Rename("users/user2.yaml", "users/org/user2.yaml")
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+
+		validator := &orgValidatorMock{}
+		org, errs, warns := LoadOrganization(fs, []OrgValidator{validator}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.True(t, validator.called)
+		assert.Equal(t, 1, len(org.Teams))
+		assert.Equal(t, 1, len(org.Repositories))
+	})
+
+	t.Run("happy path: selected warning codes are promoted to errors", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+		fs.MkdirAll("users/org", 0755)
+		fs.Rename("users/user1.yaml", "users/org/user1.yaml")
+		fs.Rename("users/user2.yaml", "users/org/user2.yaml")
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, warns := LoadOrganization(fs, nil, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+
+		_, errs, warns = LoadOrganization(fs, nil, ValidationOptions{ErrorOnWarningCodes: []string{"not-enough-owners"}})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(warns))
+	})
+}
+
+func TestReadOrganizationUsers(t *testing.T) {
+	t.Run("happy path: protected, org and external users", func(t *testing.T) {
+		fs := memfs.New()
+		for _, file := range []struct {
+			path string
+			name string
+		}{
+			{"users/protected/user1.yaml", "user1"},
+			{"users/org/user2.yaml", "user2"},
+			{"users/external/outside1.yaml", "outside1"},
+		} {
+			err := utils.WriteFile(fs, file.path, []byte(`
+apiVersion: v1
+kind: User
+name: `+file.name+`
+spec:
+  githubID: github-`+file.name+`
+`), 0644)
+			assert.Nil(t, err)
+		}
+
+		users, externalUsers, errs, warns := ReadOrganizationUsers(fs)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 2, len(users))
+		assert.NotNil(t, users["user1"])
+		assert.NotNil(t, users["user2"])
+		assert.Equal(t, 1, len(externalUsers))
+		assert.NotNil(t, externalUsers["outside1"])
+	})
+}
@@ -1,9 +1,15 @@
 package entity
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
@@ -13,25 +19,57 @@ import (
 type Repository struct {
 	Entity `yaml:",inline"`
 	Spec   struct {
+		Owner               string              `yaml:"owner,omitempty"` // declared owning team (optional), checked against the team directory
 		Writers             []string            `yaml:"writers,omitempty"`
 		Readers             []string            `yaml:"readers,omitempty"`
+		TriageTeams         []string            `yaml:"triageTeams,omitempty"`
+		MaintainTeams       []string            `yaml:"maintainTeams,omitempty"`
 		ExternalUserReaders []string            `yaml:"externalUserReaders,omitempty"`
 		ExternalUserWriters []string            `yaml:"externalUserWriters,omitempty"`
-		IsPublic            bool                `yaml:"public,omitempty"`
+		IsPublic            bool                `yaml:"public,omitempty"`              // deprecated: use visibility
+		Visibility          string              `yaml:"visibility,omitempty"`          // public or private, resolved into IsPublic when read
+		VisibilityOverride  bool                `yaml:"visibility_override,omitempty"` // the visibility intentionally differs from the owning team defaultVisibility
 		AllowAutoMerge      bool                `yaml:"allow_auto_merge,omitempty"`
 		DeleteBranchOnMerge bool                `yaml:"delete_branch_on_merge,omitempty"`
 		AllowUpdateBranch   bool                `yaml:"allow_update_branch,omitempty"`
+		DefaultBranch       string              `yaml:"default_branch,omitempty"`       // used to resolve ~DEFAULT_BRANCH, set on Github when defined
+		HasIssues           *bool               `yaml:"has_issues,omitempty"`           // nil: Github default (enabled)
+		HasDiscussions      *bool               `yaml:"has_discussions,omitempty"`      // nil: not managed
+		AllowMergeCommit    *bool               `yaml:"allow_merge_commit,omitempty"`   // nil: Github default (enabled)
+		AllowSquashMerge    *bool               `yaml:"allow_squash_merge,omitempty"`   // nil: Github default (enabled)
+		AllowRebaseMerge    *bool               `yaml:"allow_rebase_merge,omitempty"`   // nil: Github default (enabled)
+		MergeCommitTitle    string              `yaml:"merge_commit_title,omitempty"`   // PR_TITLE or MERGE_MESSAGE
+		MergeCommitMessage  string              `yaml:"merge_commit_message,omitempty"` // PR_BODY, PR_TITLE or BLANK
 		Rulesets            []RepositoryRuleSet `yaml:"rulesets,omitempty"`
+		ProtectionPreset    string              `yaml:"protection_preset,omitempty"` // expanded into an inline ruleset, see ProtectionPresets
+		ProtectedTags       []string            `yaml:"protected_tags,omitempty"`    // tags patterns, expanded into a tag ruleset (no deletion, no force push)
+		PrimaryLanguage     string              `yaml:"primary_language,omitempty"`  // informative, used by policy validators
+		Topics              []string            `yaml:"topics,omitempty"`            // added to the owning team defaultTopics
+		Properties          map[string]string   `yaml:"properties,omitempty"`        // custom properties, i.e. used by rulesets conditions
+		// contributors must sign off the commits made through the web interface (nil: not managed)
+		WebCommitSignoffRequired *bool `yaml:"web_commit_signoff_required,omitempty"`
+		// Dependabot alerts (nil: not managed)
+		DependabotAlerts *bool `yaml:"dependabot_alerts,omitempty"`
+		// Dependabot security updates: only checked against dependabot_alerts, not applied to Github
+		DependabotSecurityUpdates *bool `yaml:"dependabot_security_updates,omitempty"`
 	} `yaml:"spec,omitempty"`
-	Archived      bool    `yaml:"archived,omitempty"` // implicit: will be set by Goliac
-	Owner         *string `yaml:"-"`                  // implicit. team name owning the repo (if any)
-	RenameTo      string  `yaml:"renameTo,omitempty"`
-	DirectoryPath string  `yaml:"-"` // used to know where to rename the repository
+	Archived                  bool              `yaml:"archived,omitempty"` // implicit: will be set by Goliac
+	ArchivedReason            string            `yaml:"archivedReason,omitempty"`
+	Owner                     *string           `yaml:"-"` // implicit. team name owning the repo (if any)
+	RenameTo                  string            `yaml:"renameTo,omitempty"`
+	Include                   []string          `yaml:"include,omitempty"` // rulesets fragments files, relative to the repository file
+	DirectoryPath             string            `yaml:"-"`                 // used to know where to rename the repository
+	Filename                  string            `yaml:"-"`                 // file the repository is defined in (.yaml or .yml)
+	Deleted                   bool              `yaml:"-"`                 // set by the reconciler when the repository will be deleted
+	ExpectedArchived          bool              `yaml:"-"`                 // implicit: loaded from the archived directory
+	ExternalUserWritersExpiry map[string]string `yaml:"-"`                 // external writer -> expiry date (YYYY-MM-DD)
+	DeprecatedFields          map[string]string `yaml:"-"`                 // deprecated fields set in the file -> replacement
 }
 
 type RepositoryRuleSet struct {
 	RuleSetDefinition `yaml:",inline"`
 	Name              string `yaml:"name"`
+	Note              string `yaml:"note,omitempty"` // cosmetic, i.e. why an evaluate ruleset is in dry-run
 }
 
 /*
@@ -39,21 +77,416 @@ type RepositoryRuleSet struct {
  * The next step is to validate the Repository object using the Validate method
  */
 func NewRepository(fs billy.Filesystem, filename string) (*Repository, error) {
+	return NewRepositoryWithVariables(fs, filename, nil, false)
+}
+
+/*
+ * NewRepositoryWithVariables reads a file and returns a Repository object,
+ * substituting the ${VARIABLE} placeholders found in the spec string fields
+ * with the variables values.
+ * If strict is true, an unresolved placeholder is an error
+ */
+func NewRepositoryWithVariables(fs billy.Filesystem, filename string, variables map[string]string, strict bool) (*Repository, error) {
+	return readRepository(fs, filename, variables, strict, defaultFieldDeprecations())
+}
+
+// readRepository is NewRepositoryWithVariables with the given deprecated fields
+func readRepository(fs billy.Filesystem, filename string, variables map[string]string, strict bool, deprecations fieldDeprecations) (*Repository, error) {
 	filecontent, err := utils.ReadFile(fs, filename)
 	if err != nil {
 		return nil, err
 	}
 
+	var document yaml.Node
+	err = yaml.Unmarshal(filecontent, &document)
+	if err != nil {
+		return nil, newParseError(filename, err)
+	}
+
+	// check first what kind of entity it is, before decoding it as a repository
+	entity := &Entity{}
+	if err := document.Decode(entity); err != nil {
+		return nil, newParseError(filename, err)
+	}
+	if entity.ApiVersion != "v1" {
+		return nil, fmt.Errorf("invalid apiVersion: %s (check repository filename %s)", entity.ApiVersion, filename)
+	}
+	if entity.Kind != "Repository" {
+		return nil, fmt.Errorf("file %s is not a Repository (kind: %s)", filename, entity.Kind)
+	}
+
+	var expiries map[string]string
+	var deprecatedFields map[string]string
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "spec" {
+				if err := substituteVariables(root.Content[i+1], variables, strict); err != nil {
+					return nil, fmt.Errorf("%v (check repository filename %s)", err, filename)
+				}
+				expiries, err = extractExternalWritersExpiry(root.Content[i+1])
+				if err != nil {
+					return nil, fmt.Errorf("%v (check repository filename %s)", err, filename)
+				}
+				deprecatedFields = findRepositoryDeprecatedFields(root.Content[i+1], deprecations)
+			}
+		}
+	}
+
 	repository := &Repository{}
-	err = yaml.Unmarshal(filecontent, repository)
+	err = document.Decode(repository)
 	if err != nil {
+		return nil, newParseError(filename, err)
+	}
+	repository.ExternalUserWritersExpiry = expiries
+	repository.DeprecatedFields = deprecatedFields
+	if err := repository.resolveVisibility(); err != nil {
+		return nil, fmt.Errorf("%v (check repository filename %s)", err, filename)
+	}
+	if err := repository.mergeIncludes(fs, filename); err != nil {
 		return nil, err
 	}
+	if repository.Spec.ProtectionPreset != "" {
+		preset, ok := ProtectionPresets[repository.Spec.ProtectionPreset]
+		if !ok {
+			return nil, fmt.Errorf("unknown protection_preset: %s (check repository filename %s)", repository.Spec.ProtectionPreset, filename)
+		}
+		repository.Spec.Rulesets = append(repository.Spec.Rulesets, RepositoryRuleSet{
+			RuleSetDefinition: preset.RuleSetDefinition.clone(),
+			Name:              preset.Name,
+		})
+	}
+	if len(repository.Spec.ProtectedTags) > 0 {
+		ruleset, err := protectedTagsRuleset(repository.Spec.ProtectedTags)
+		if err != nil {
+			return nil, fmt.Errorf("%v (check repository filename %s)", err, filename)
+		}
+		repository.Spec.Rulesets = append(repository.Spec.Rulesets, ruleset)
+	}
+	for i := range repository.Spec.Rulesets {
+		repository.Spec.Rulesets[i].Enforcement, err = NormalizeEnforcement(repository.Spec.Rulesets[i].Enforcement)
+		if err != nil {
+			return nil, fmt.Errorf("%v for ruleset %s (check repository filename %s)", err, repository.Spec.Rulesets[i].Name, filename)
+		}
+		repository.Spec.Rulesets[i].normalize()
+	}
 	repository.DirectoryPath = filepath.Dir(filename)
+	repository.Filename = filename
 
 	return repository, nil
 }
 
+/*
+ * ProtectionPresets are the inline rulesets templates a repository can
+ * use through its protection_preset, to protect its default branch
+ */
+var ProtectionPresets = map[string]RepositoryRuleSet{
+	"standard": {
+		Name: "protection-standard",
+		RuleSetDefinition: RuleSetDefinition{
+			Enforcement: "active",
+			Conditions:  RuleSetConditions{Include: []string{"~DEFAULT_BRANCH"}},
+			Rules: []RuleSetRule{
+				{Ruletype: "pull_request", Parameters: RuleSetParameters{RequiredApprovingReviewCount: 1}},
+				{Ruletype: "deletion"},
+			},
+		},
+	},
+	"strict": {
+		Name: "protection-strict",
+		RuleSetDefinition: RuleSetDefinition{
+			Enforcement: "active",
+			Conditions:  RuleSetConditions{Include: []string{"~DEFAULT_BRANCH"}},
+			Rules: []RuleSetRule{
+				{Ruletype: "pull_request", Parameters: RuleSetParameters{
+					RequiredApprovingReviewCount:   2,
+					DismissStaleReviewsOnPush:      true,
+					RequireCodeOwnerReview:         true,
+					RequiredReviewThreadResolution: true,
+				}},
+				{Ruletype: "deletion"},
+				{Ruletype: "non_fast_forward"},
+			},
+		},
+	},
+}
+
+// name of the inline ruleset generated from the repository protected_tags
+const ProtectedTagsRulesetName = "protected-tags"
+
+/*
+ * protectedTagsRuleset returns the tag ruleset protecting the tags patterns
+ * (i.e. v*) from deletion and overwrite
+ */
+func protectedTagsRuleset(patterns []string) (RepositoryRuleSet, error) {
+	include := []string{}
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return RepositoryRuleSet{}, fmt.Errorf("invalid protected_tags: a pattern cannot be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return RepositoryRuleSet{}, fmt.Errorf("invalid protected_tags: pattern %s: %v", pattern, err)
+		}
+		include = append(include, "refs/tags/"+pattern)
+	}
+	return RepositoryRuleSet{
+		Name: ProtectedTagsRulesetName,
+		RuleSetDefinition: RuleSetDefinition{
+			Target:      "tag",
+			Enforcement: "active",
+			Conditions:  RuleSetConditions{Include: include},
+			Rules: []RuleSetRule{
+				{Ruletype: "deletion"},
+				{Ruletype: "non_fast_forward"},
+			},
+		},
+	}, nil
+}
+
+/*
+ * findRepositoryDeprecatedFields returns the deprecated fields set in the
+ * repository spec and in its inline rulesets
+ */
+func findRepositoryDeprecatedFields(spec *yaml.Node, deprecations fieldDeprecations) map[string]string {
+	found := findDeprecatedFields(spec, deprecations.repository, "spec.", nil)
+	if spec.Kind != yaml.MappingNode {
+		return found
+	}
+	for i := 0; i+1 < len(spec.Content); i += 2 {
+		if spec.Content[i].Value != "rulesets" || spec.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, ruleset := range spec.Content[i+1].Content {
+			found = findDeprecatedFields(ruleset, deprecations.ruleset, "spec.rulesets.", found)
+		}
+	}
+	return found
+}
+
+/*
+ * resolveVisibility sets IsPublic from the visibility field, so the rest of
+ * Goliac only deals with IsPublic whichever field the file uses
+ */
+func (r *Repository) resolveVisibility() error {
+	switch r.Spec.Visibility {
+	case "":
+		return nil
+	case "public", "private":
+	default:
+		return fmt.Errorf("invalid visibility: %s (must be public or private)", r.Spec.Visibility)
+	}
+	if r.Spec.IsPublic && r.Spec.Visibility == "private" {
+		return fmt.Errorf("public: true conflicts with visibility: private")
+	}
+	r.Spec.IsPublic = r.Spec.Visibility == "public"
+	r.Spec.Visibility = ""
+	return nil
+}
+
+/*
+ * extractExternalWritersExpiry supports the object form of the external
+ * writers ({name: ..., expiry: YYYY-MM-DD}) next to the simple string form:
+ * the objects are replaced by their name and the expiries are returned
+ */
+func extractExternalWritersExpiry(spec *yaml.Node) (map[string]string, error) {
+	if spec.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var expiries map[string]string
+	for i := 0; i+1 < len(spec.Content); i += 2 {
+		if spec.Content[i].Value != "externalUserWriters" || spec.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, item := range spec.Content[i+1].Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			var grant struct {
+				Name   string `yaml:"name"`
+				Expiry string `yaml:"expiry"`
+			}
+			if err := item.Decode(&grant); err != nil {
+				return nil, err
+			}
+			if grant.Name == "" {
+				return nil, fmt.Errorf("invalid externalUserWriters: each entry must have a name")
+			}
+			if expiries == nil {
+				expiries = make(map[string]string)
+			}
+			expiries[grant.Name] = grant.Expiry
+			spec.Content[i+1].Content[j] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: grant.Name}
+		}
+	}
+	return expiries, nil
+}
+
+/*
+ * ExternalUserWriterExpired returns true if the external writer grant has
+ * an expiry date which is passed
+ */
+func (r *Repository) ExternalUserWriterExpired(name string, now time.Time) bool {
+	expiry, ok := r.ExternalUserWritersExpiry[name]
+	if !ok || expiry == "" {
+		return false
+	}
+	date, err := time.Parse("2006-01-02", expiry)
+	if err != nil {
+		return false
+	}
+	// the access is granted until the end of the expiry day
+	return !now.Before(date.AddDate(0, 0, 1))
+}
+
+/*
+ * RepositoryFragment is a shared file, included by repositories, defining rulesets.
+ * Fragments are usually stored in a directory starting with a '.'
+ * so they are not read as repositories
+ */
+type RepositoryFragment struct {
+	Rulesets []RepositoryRuleSet `yaml:"rulesets,omitempty"`
+}
+
+/*
+ * mergeIncludes appends the rulesets of the included fragments to the
+ * repository rulesets, and checks the merged rulesets names are uniq
+ */
+func (r *Repository) mergeIncludes(fs billy.Filesystem, filename string) error {
+	origins := make(map[string]string)
+	for _, ruleset := range r.Spec.Rulesets {
+		origins[ruleset.Name] = filename
+	}
+
+	for _, include := range r.Include {
+		fragmentname := filepath.Join(filepath.Dir(filename), include)
+		filecontent, err := utils.ReadFile(fs, fragmentname)
+		if err != nil {
+			return fmt.Errorf("not able to read included fragment %s (check repository filename %s): %v", fragmentname, filename, err)
+		}
+		fragment := RepositoryFragment{}
+		if err := yaml.Unmarshal(filecontent, &fragment); err != nil {
+			return fmt.Errorf("not able to parse included fragment %s (check repository filename %s): %v", fragmentname, filename, err)
+		}
+		for _, ruleset := range fragment.Rulesets {
+			if origin, ok := origins[ruleset.Name]; ok {
+				return fmt.Errorf("invalid ruleset: each ruleset must have a uniq name, found 2 times %s (defined in %s and %s)", ruleset.Name, origin, fragmentname)
+			}
+			origins[ruleset.Name] = fragmentname
+			r.Spec.Rulesets = append(r.Spec.Rulesets, ruleset)
+		}
+	}
+	return nil
+}
+
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+/*
+ * substituteVariables replaces recursively the ${VARIABLE} placeholders
+ * of all string scalars of a yaml node
+ */
+func substituteVariables(node *yaml.Node, variables map[string]string, strict bool) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		var unresolved error
+		node.Value = variablePattern.ReplaceAllStringFunc(node.Value, func(placeholder string) string {
+			name := placeholder[2 : len(placeholder)-1]
+			if value, ok := variables[name]; ok {
+				return value
+			}
+			if strict && unresolved == nil {
+				unresolved = fmt.Errorf("unresolved variable %s", placeholder)
+			}
+			return placeholder
+		})
+		return unresolved
+	}
+	for _, child := range node.Content {
+		if err := substituteVariables(child, variables, strict); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maximum number of topics of a Github repository
+const RepositoryMaxTopics = 20
+
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+/*
+ * mergeTopics returns the default topics followed by the topics,
+ * without duplicates
+ */
+func mergeTopics(defaults []string, topics []string) []string {
+	if len(defaults) == 0 {
+		return topics
+	}
+	merged := []string{}
+	seen := make(map[string]bool)
+	for _, topic := range append(append([]string{}, defaults...), topics...) {
+		if !seen[topic] {
+			seen[topic] = true
+			merged = append(merged, topic)
+		}
+	}
+	return merged
+}
+
+/*
+ * repositoryNameFromFile returns the repository name expected for a
+ * repository filename. By default it is the filename without its extension,
+ * unless another file naming convention is set in the validation options
+ */
+func repositoryNameFromFile(path string, opts ValidationOptions) string {
+	if opts.RepositoryNameFromFile != nil {
+		return opts.RepositoryNameFromFile(path)
+	}
+	filename := filepath.Base(path)
+	return filename[:len(filename)-len(filepath.Ext(filename))]
+}
+
+/*
+ * applyTeamPolicies appends the owning team policy rulesets to the repository
+ * inline rulesets (like the protection presets), so they are validated and
+ * reconciled with them
+ */
+func (r *Repository) applyTeamPolicies(team *Team) {
+	for _, policy := range team.Spec.PolicyRulesets {
+		r.Spec.Rulesets = append(r.Spec.Rulesets, RepositoryRuleSet{
+			RuleSetDefinition: policy.RuleSetDefinition.clone(),
+			Name:              policy.Name,
+			Note:              policy.Note,
+		})
+	}
+}
+
+/*
+ * injectOwnerReviewer adds the owning team to the required reviewers of
+ * the inline pull_request rulesets (if not already present)
+ */
+func (r *Repository) injectOwnerReviewer() {
+	if r.Owner == nil {
+		return
+	}
+	for i := range r.Spec.Rulesets {
+		for j := range r.Spec.Rulesets[i].Rules {
+			rule := &r.Spec.Rulesets[i].Rules[j]
+			if rule.Ruletype != "pull_request" {
+				continue
+			}
+			found := false
+			for _, reviewer := range rule.Parameters.RequiredReviewers {
+				if reviewer == *r.Owner {
+					found = true
+					break
+				}
+			}
+			if !found {
+				rule.Parameters.RequiredReviewers = append(rule.Parameters.RequiredReviewers, *r.Owner)
+			}
+		}
+	}
+}
+
 /**
  * ReadRepositories reads all the files in the dirname directory and
  * add them to the owner's team and returns
@@ -61,7 +494,7 @@ func NewRepository(fs billy.Filesystem, filename string) (*Repository, error) {
  * - a slice of errors that must stop the validation process
  * - a slice of warning that must not stop the validation process
  */
-func ReadRepositories(fs billy.Filesystem, archivedDirname string, teamDirname string, teams map[string]*Team, externalUsers map[string]*User) (map[string]*Repository, []error, []Warning) {
+func ReadRepositories(fs billy.Filesystem, archivedDirname string, teamDirname string, teams map[string]*Team, externalUsers map[string]*User, opts ValidationOptions) (map[string]*Repository, []error, []Warning) {
 	errors := []error{}
 	warning := []Warning{}
 	repos := make(map[string]*Repository)
@@ -87,18 +520,28 @@ func ReadRepositories(fs billy.Filesystem, archivedDirname string, teamDirname s
 			if entry.Name()[0] == '.' {
 				continue
 			}
-			if !strings.HasSuffix(entry.Name(), ".yaml") {
-				warning = append(warning, fmt.Errorf("file %s doesn't have a .yaml extension", entry.Name()))
+			filename := filepath.Join(archivedDirname, entry.Name())
+			if !isYamlFile(entry.Name()) {
+				warning = append(warning, withFile(filename, NewCodedWarning("non-yaml-file", "file %s doesn't have a .yaml extension", entry.Name())))
 				continue
 			}
-			repo, err := NewRepository(fs, filepath.Join(archivedDirname, entry.Name()))
+			if filepath.Ext(entry.Name()) == ".yml" {
+				warning = append(warning, ymlExtensionWarning(filename))
+			}
+			repo, err := NewRepositoryWithVariables(fs, filename, opts.repositoryVariables(""), opts.StrictVariables)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, withFile(filename, err))
 			} else {
-				if err := repo.Validate(filepath.Join(archivedDirname, entry.Name()), teams, externalUsers); err != nil {
-					errors = append(errors, err)
+				repo.Archived = true
+				repo.ExpectedArchived = true
+				err, warns := repo.Validate(filename, teams, externalUsers, opts)
+				warning = append(warning, warningsWithFile(filename, warns)...)
+				if err != nil {
+					errors = append(errors, withFile(filename, err))
+				} else if existing, exist := repos[repo.Name]; exist {
+					// i.e. repo.yaml and repo.yml
+					errors = append(errors, withFile(filename, fmt.Errorf("Repository %s defined in 2 places (check %s and %s)", repo.Name, repo.describeDefinition(), existing.describeDefinition())))
 				} else {
-					repo.Archived = true
 					repos[repo.Name] = repo
 				}
 			}
@@ -123,19 +566,34 @@ func ReadRepositories(fs billy.Filesystem, archivedDirname string, teamDirname s
 
 	for _, team := range entries {
 		if team.IsDir() {
-			suberrs, subwarns := recursiveReadRepositories(fs, archivedDirname, filepath.Join(teamDirname, team.Name()), team.Name(), repos, teams, externalUsers)
+			suberrs, subwarns := recursiveReadRepositories(fs, archivedDirname, filepath.Join(teamDirname, team.Name()), team.Name(), repos, teams, externalUsers, 1, opts)
 			errors = append(errors, suberrs...)
 			warning = append(warning, subwarns...)
 		}
 	}
 
+	for _, repo := range repos {
+		warning = append(warning, repo.CheckPlacement(teamDirname, teams)...)
+	}
+
+	errors = append(errors, validateGlobalUniqueness(repos)...)
+
+	crosserrs, crosswarns := crossValidateRepositories(repos, teams)
+	errors = append(errors, crosserrs...)
+	warning = append(warning, crosswarns...)
+
 	return repos, errors, warning
 }
 
-func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, teamDirPath string, teamName string, repos map[string]*Repository, teams map[string]*Team, externalUsers map[string]*User) ([]error, []Warning) {
+func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, teamDirPath string, teamName string, repos map[string]*Repository, teams map[string]*Team, externalUsers map[string]*User, depth int, opts ValidationOptions) ([]error, []Warning) {
 	errors := []error{}
 	warnings := []Warning{}
 
+	if opts.RepositoryMaxTeamDepth > 0 && depth > opts.RepositoryMaxTeamDepth {
+		errors = append(errors, fmt.Errorf("team directory %s is nested too deeply: maximum depth is %d", teamDirPath, opts.RepositoryMaxTeamDepth))
+		return errors, warnings
+	}
+
 	subentries, err := fs.ReadDir(teamDirPath)
 	if err != nil {
 		errors = append(errors, err)
@@ -143,28 +601,44 @@ func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, team
 	}
 	for _, sube := range subentries {
 		if sube.IsDir() && sube.Name()[0] != '.' {
-			suberrs, subwarns := recursiveReadRepositories(fs, archivedDirPath, filepath.Join(teamDirPath, sube.Name()), sube.Name(), repos, teams, externalUsers)
+			suberrs, subwarns := recursiveReadRepositories(fs, archivedDirPath, filepath.Join(teamDirPath, sube.Name()), sube.Name(), repos, teams, externalUsers, depth+1, opts)
 			errors = append(errors, suberrs...)
 			warnings = append(warnings, subwarns...)
 		}
-		if !sube.IsDir() && filepath.Ext(sube.Name()) == ".yaml" && sube.Name() != "team.yaml" {
-			repo, err := NewRepository(fs, filepath.Join(teamDirPath, sube.Name()))
+		if !sube.IsDir() && isYamlFile(sube.Name()) && sube.Name() != "team.yaml" && sube.Name() != "team.yml" {
+			filename := filepath.Join(teamDirPath, sube.Name())
+			if filepath.Ext(sube.Name()) == ".yml" {
+				warnings = append(warnings, ymlExtensionWarning(filename))
+			}
+			team, ok := teams[teamName]
+			if !ok {
+				errors = append(errors, withFile(filename, fmt.Errorf("repository file %s is defined under %s which is not a defined team (missing team.yaml?)", filename, teamDirPath)))
+				continue
+			}
+			if !team.CanOwnRepositories() {
+				errors = append(errors, withFile(filename, fmt.Errorf("repository file %s is defined under team %s which cannot own repositories", filename, teamName)))
+				continue
+			}
+			repo, err := NewRepositoryWithVariables(fs, filename, opts.repositoryVariables(teamName), opts.StrictVariables)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, withFile(filename, err))
 			} else {
-				if err := repo.Validate(filepath.Join(teamDirPath, sube.Name()), teams, externalUsers); err != nil {
-					errors = append(errors, err)
+				teamname := teamName
+				repo.Owner = &teamname
+				repo.Spec.Topics = mergeTopics(team.Spec.DefaultTopics, repo.Spec.Topics)
+				repo.applyTeamPolicies(team)
+				if opts.OwnerRequiredReviewer {
+					repo.injectOwnerReviewer()
+				}
+				err, warns := repo.Validate(filename, teams, externalUsers, opts)
+				warnings = append(warnings, warningsWithFile(filename, warns)...)
+				if err != nil {
+					errors = append(errors, withFile(filename, err))
 				} else {
 					// check if the repository doesn't already exists
-					if _, exist := repos[repo.Name]; exist {
-						existing := filepath.Join(archivedDirPath, repo.Name)
-						if repos[repo.Name].Owner != nil {
-							existing = filepath.Join(*repos[repo.Name].Owner, repo.Name)
-						}
-						errors = append(errors, fmt.Errorf("Repository %s defined in 2 places (check %s and %s)", repo.Name, filepath.Join(teamDirPath, sube.Name()), existing))
+					if existing, exist := repos[repo.Name]; exist {
+						errors = append(errors, withFile(filename, fmt.Errorf("Repository %s defined in 2 places (check %s and %s)", repo.Name, repo.describeDefinition(), existing.describeDefinition())))
 					} else {
-						teamname := teamName
-						repo.Owner = &teamname
 						repo.Archived = false
 						repos[repo.Name] = repo
 					}
@@ -175,65 +649,1163 @@ func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, team
 	return errors, warnings
 }
 
-func (r *Repository) Validate(filename string, teams map[string]*Team, externalUsers map[string]*User) error {
+func (r *Repository) Validate(filename string, teams map[string]*Team, externalUsers map[string]*User, opts ValidationOptions) (error, []Warning) {
+	warnings := deprecationWarnings(r.DeprecatedFields, filename)
+
+	// the apiVersion and kind are checked when reading the file (NewRepository)
+	if r.Name == "" {
+		return fmt.Errorf("name is empty (check repository filename %s)", filename), warnings
+	}
 
-	if r.ApiVersion != "v1" {
-		return fmt.Errorf("invalid apiVersion: %s (check repository filename %s)", r.ApiVersion, filename)
+	expectedName := repositoryNameFromFile(filename, opts)
+	if r.Name != expectedName {
+		return fmt.Errorf("invalid name: %s for repository filename %s", r.Name, filename), warnings
 	}
 
-	if r.Kind != "Repository" {
-		return fmt.Errorf("invalid kind: %s (check repository filename %s)", r.Kind, filename)
+	if opts.repositoryReservedName(r.Name) != "" {
+		return fmt.Errorf("invalid name: %s is a reserved name (check repository filename %s)", r.Name, filename), warnings
 	}
 
-	if r.Name == "" {
-		return fmt.Errorf("name is empty (check repository filename %s)", filename)
+	if opts.RepositoryNamePattern != nil && !opts.RepositoryNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid name: %s doesn't match the repositories naming policy %s (check repository filename %s)", r.Name, opts.RepositoryNamePattern.String(), filename), warnings
 	}
 
-	filename = filepath.Base(filename)
-	if r.Name != filename[:len(filename)-len(filepath.Ext(filename))] {
-		return fmt.Errorf("invalid name: %s for repository filename %s", r.Name, filename)
+	if r.Spec.Owner != "" {
+		if _, ok := teams[r.Spec.Owner]; !ok {
+			return fmt.Errorf("invalid owner: %s doesn't exist (check repository filename %s)", r.Spec.Owner, filename), warnings
+		}
 	}
 
 	for _, writer := range r.Spec.Writers {
 		if _, ok := teams[writer]; !ok {
-			return fmt.Errorf("invalid writer: %s doesn't exist (check repository filename %s)", writer, filename)
+			return fmt.Errorf("invalid writer: %s doesn't exist (check repository filename %s)", writer, filename), warnings
+		}
+		if r.Owner != nil && *r.Owner == writer {
+			warnings = append(warnings, NewCodedWarning("redundant-grant", "writer %s is the owning team and already has admin access (check repository filename %s)", writer, filename))
 		}
 	}
 	for _, reader := range r.Spec.Readers {
 		if _, ok := teams[reader]; !ok {
-			return fmt.Errorf("invalid reader: %s doesn't exist (check repository filename %s)", reader, filename)
+			return fmt.Errorf("invalid reader: %s doesn't exist (check repository filename %s)", reader, filename), warnings
+		}
+		if r.Owner != nil && *r.Owner == reader {
+			warnings = append(warnings, NewCodedWarning("redundant-grant", "reader %s is the owning team and already has admin access (check repository filename %s)", reader, filename))
+		}
+	}
+	for _, team := range append(append([]string{}, r.Spec.TriageTeams...), r.Spec.MaintainTeams...) {
+		if _, ok := teams[team]; !ok {
+			return fmt.Errorf("invalid team: %s doesn't exist (check repository filename %s)", team, filename), warnings
 		}
 	}
 
+	// a team must be granted only one permission level
+	grantedIn := make(map[string]string)
+	for _, grant := range []struct {
+		list  string
+		teams []string
+	}{
+		{"readers", r.Spec.Readers},
+		{"triageTeams", r.Spec.TriageTeams},
+		{"writers", r.Spec.Writers},
+		{"maintainTeams", r.Spec.MaintainTeams},
+	} {
+		for _, team := range grant.teams {
+			if other, ok := grantedIn[team]; ok && other != grant.list {
+				return fmt.Errorf("invalid grant: team %s is listed in both %s and %s (check repository filename %s)", team, other, grant.list, filename), warnings
+			}
+			grantedIn[team] = grant.list
+		}
+	}
+
+	// someone must be able to manage the repository settings: the owner (admin) or a maintain team
+	if !r.Archived && anyTeamWithMembers(r.grantedTeams(), teams) && !anyTeamWithMembers(r.administratorTeams(), teams) {
+		return fmt.Errorf("invalid grants: repository %s is granted to teams but neither its owner nor its maintainTeams have members, no one can administer it (check repository filename %s)", r.Name, filename), warnings
+	}
+
 	for _, externalUserReader := range r.Spec.ExternalUserReaders {
 		if _, ok := externalUsers[externalUserReader]; !ok {
-			return fmt.Errorf("invalid externalUserReader: %s doesn't exist in repository filename %s", externalUserReader, filename)
+			return fmt.Errorf("invalid externalUserReader: %s doesn't exist in repository filename %s", externalUserReader, filename), warnings
 		}
 	}
 
 	for _, externalUserWriter := range r.Spec.ExternalUserWriters {
 		if _, ok := externalUsers[externalUserWriter]; !ok {
-			return fmt.Errorf("invalid externalUserWriter: %s doesn't exist in repository filename %s", externalUserWriter, filename)
+			return fmt.Errorf("invalid externalUserWriter: %s doesn't exist in repository filename %s", externalUserWriter, filename), warnings
+		}
+		if ExternalUserRoles[externalUsers[externalUserWriter].Spec.Role] == "read" {
+			warnings = append(warnings, NewCodedWarning("read-only-writer", "externalUserWriter %s has the read-only role %s (check repository filename %s)", externalUserWriter, externalUsers[externalUserWriter].Spec.Role, filename))
+		}
+	}
+
+	if r.Archived && strings.TrimSpace(r.ArchivedReason) == "" {
+		warnings = append(warnings, NewCodedWarning("missing-archived-reason", "archived repository %s has no archivedReason (check repository filename %s)", r.Name, filename))
+	}
+
+	// security guardrail: an external user must never be able to administer a repository
+	for _, admin := range r.adminUsers(teams) {
+		if _, ok := externalUsers[admin]; ok {
+			return fmt.Errorf("invalid admin: external user %s would be granted admin access (check repository filename %s)", admin, filename), warnings
+		}
+	}
+
+	if len(r.Spec.PrimaryLanguage) > 50 || strings.ContainsAny(r.Spec.PrimaryLanguage, "\n\r\t") {
+		return fmt.Errorf("invalid primary_language: %q must be a short single line string (check repository filename %s)", r.Spec.PrimaryLanguage, filename), warnings
+	}
+
+	if len(r.Spec.Topics) > RepositoryMaxTopics {
+		return fmt.Errorf("invalid topics: %d topics defined, it must not exceed %d (check repository filename %s)", len(r.Spec.Topics), RepositoryMaxTopics, filename), warnings
+	}
+	for _, topic := range r.Spec.Topics {
+		if !topicPattern.MatchString(topic) {
+			return fmt.Errorf("invalid topic: %s must be lowercase letters, numbers and hyphens, up to 50 characters (check repository filename %s)", topic, filename), warnings
+		}
+	}
+
+	for key := range r.Spec.Properties {
+		if opts.RepositoryPropertyKeys != nil && !opts.RepositoryPropertyKeys[key] {
+			return fmt.Errorf("invalid properties: unknown custom property %s (check repository filename %s)", key, filename), warnings
+		}
+	}
+
+	if r.Owner != nil && !r.Spec.VisibilityOverride {
+		if team, ok := teams[*r.Owner]; ok && team.Spec.DefaultVisibility != "" && team.Spec.DefaultVisibility != r.visibility() {
+			warnings = append(warnings, NewCodedWarning("visibility-override", "repository %s is %s while team %s repositories are %s by default (set visibility_override if intended) (check repository filename %s)", r.Name, r.visibility(), *r.Owner, team.Spec.DefaultVisibility, filename))
+		}
+	}
+
+	// the default branch should be protected by a single source: the repository or its team policy
+	if r.Owner != nil {
+		if team, ok := teams[*r.Owner]; ok {
+			policies := make(map[string]bool)
+			for _, policy := range team.Spec.PolicyRulesets {
+				policies[policy.Name] = true
+			}
+			for _, inline := range r.RulesetsForBranch(r.defaultBranch()) {
+				// skip the rulesets expanded from the team policies
+				if policies[inline.Name] {
+					continue
+				}
+				for _, policy := range team.Spec.PolicyRulesets {
+					if policy.matchesBranch(r.defaultBranch(), r.defaultBranch()) {
+						warnings = append(warnings, NewCodedWarning("duplicate-protection", "repository %s ruleset %s and team %s policy ruleset %s both protect the default branch (check repository filename %s)", r.Name, inline.Name, *r.Owner, policy.Name, filename))
+					}
+				}
+			}
+		}
+	}
+
+	if r.Spec.AllowAutoMerge && !r.mergeMethodEnabled() {
+		return fmt.Errorf("invalid allow_auto_merge: at least one merge method (merge commit, squash or rebase) must be enabled (check repository filename %s)", filename), warnings
+	}
+
+	// an unset allow_rebase_merge uses the Github default (enabled)
+	if r.Spec.AllowAutoMerge && (r.Spec.AllowRebaseMerge == nil || *r.Spec.AllowRebaseMerge) && !r.Spec.DeleteBranchOnMerge {
+		warnings = append(warnings, NewCodedWarning("branch-pile-up", "repository %s allows auto merge and rebase merge without delete_branch_on_merge: merged branches will pile up (check repository filename %s)", r.Name, filename))
+	}
+
+	if r.Spec.MergeCommitTitle != "" && r.Spec.MergeCommitTitle != "PR_TITLE" && r.Spec.MergeCommitTitle != "MERGE_MESSAGE" {
+		return fmt.Errorf("invalid merge_commit_title: %s must be 'PR_TITLE' or 'MERGE_MESSAGE' (check repository filename %s)", r.Spec.MergeCommitTitle, filename), warnings
+	}
+	if r.Spec.MergeCommitMessage != "" && r.Spec.MergeCommitMessage != "PR_BODY" && r.Spec.MergeCommitMessage != "PR_TITLE" && r.Spec.MergeCommitMessage != "BLANK" {
+		return fmt.Errorf("invalid merge_commit_message: %s must be 'PR_BODY', 'PR_TITLE' or 'BLANK' (check repository filename %s)", r.Spec.MergeCommitMessage, filename), warnings
+	}
+	if (r.Spec.MergeCommitTitle != "" || r.Spec.MergeCommitMessage != "") && r.Spec.AllowMergeCommit != nil && !*r.Spec.AllowMergeCommit {
+		warnings = append(warnings, NewCodedWarning("unused-merge-commit", "repository %s defines merge commit title/message but merge commits are disabled (check repository filename %s)", r.Name, filename))
+	}
+
+	deperrs, depwarns := r.ValidateFeatureDependencies()
+	for _, warn := range depwarns {
+		if w, ok := warn.(*CodedWarning); ok {
+			warnings = append(warnings, NewCodedWarning(w.Code, "repository %s: %v (check repository filename %s)", r.Name, w.Err, filename))
+		} else {
+			warnings = append(warnings, warn)
+		}
+	}
+	if len(deperrs) > 0 {
+		return fmt.Errorf("%v (check repository filename %s)", deperrs[0], filename), warnings
+	}
+	if r.Spec.DependabotSecurityUpdates != nil {
+		warnings = append(warnings, NewCodedWarning("unmanaged-field", "repository %s: dependabot_security_updates is not applied by Goliac, set it in Github (check repository filename %s)", r.Name, filename))
+	}
+
+	expiringWriters := make([]string, 0, len(r.ExternalUserWritersExpiry))
+	for writer := range r.ExternalUserWritersExpiry {
+		expiringWriters = append(expiringWriters, writer)
+	}
+	sort.Strings(expiringWriters)
+	for _, writer := range expiringWriters {
+		expiry := r.ExternalUserWritersExpiry[writer]
+		if _, err := time.Parse("2006-01-02", expiry); err != nil {
+			return fmt.Errorf("invalid externalUserWriters expiry: %q for %s must be a YYYY-MM-DD date (check repository filename %s)", expiry, writer, filename), warnings
+		}
+		// the expired grants are revoked by the reconciler
+		if r.ExternalUserWriterExpired(writer, time.Now()) {
+			warnings = append(warnings, NewCodedWarning("expired-grant", "externalUserWriters access of %s expired on %s and will be revoked (check repository filename %s)", writer, expiry, filename))
 		}
 	}
 
 	rulesetname := make(map[string]bool)
 	for _, ruleset := range r.Spec.Rulesets {
 		if ruleset.Name == "" {
-			return fmt.Errorf("invalid ruleset: each ruleset must have a name")
+			return fmt.Errorf("invalid ruleset: each ruleset must have a name (check repository filename %s)", filename), warnings
 		}
-		if ruleset.Enforcement != "disable" && ruleset.Enforcement != "active" && ruleset.Enforcement != "evaluate" {
-			return fmt.Errorf("invalid ruleset %s enforcement: it must be 'disable','active' or 'evaluate'", ruleset.Name)
+		if err := validateRuleSetName(ruleset.Name, filename); err != nil {
+			return err, warnings
+		}
+		if ruleset.Enforcement != "disabled" && ruleset.Enforcement != "active" && ruleset.Enforcement != "evaluate" {
+			return fmt.Errorf("invalid ruleset %s enforcement: it must be 'disabled','active' or 'evaluate'", ruleset.Name), warnings
 		}
 		if _, ok := rulesetname[ruleset.Name]; ok {
-			return fmt.Errorf("invalid ruleset: each ruleset must have a uniq name, found 2 times %s", ruleset.Name)
+			return fmt.Errorf("invalid ruleset: each ruleset must have a uniq name, found 2 times %s", ruleset.Name), warnings
 		}
 		rulesetname[ruleset.Name] = true
+		if r.Spec.IsPublic && opts.AppVisibilityScopes != nil {
+			for _, app := range ruleset.BypassApps {
+				if opts.AppVisibilityScopes[app.AppName] == "internal" {
+					return fmt.Errorf("invalid ruleset %s: bypass app %s is internal-only and cannot operate on public repository %s (check repository filename %s)", ruleset.Name, app.AppName, r.Name, filename), warnings
+				}
+			}
+		}
+		if err := ruleset.validate(ruleset.Name, filename, opts); err != nil {
+			return err, warnings
+		}
+		if errs := ruleset.validateReviewers(ruleset.Name, teams); len(errs) > 0 {
+			return fmt.Errorf("%v (check repository filename %s)", errs[0], filename), warnings
+		}
+		warnings = append(warnings, ruleset.warnings(ruleset.Name, filename, opts)...)
+		if ruleset.Enforcement == "evaluate" && ruleset.Note == "" {
+			warnings = append(warnings, NewCodedWarning("unexplained-evaluate", "ruleset %s is in evaluate mode without a note explaining why (check repository filename %s)", ruleset.Name, filename))
+		}
+		if ruleset.includesDefaultBranchTwice(r.defaultBranch()) {
+			warnings = append(warnings, NewCodedWarning("redundant-targeting", "ruleset %s includes both ~DEFAULT_BRANCH and %s, the default branch: the targeting is redundant (check repository filename %s)", ruleset.Name, r.defaultBranch(), filename))
+		}
+	}
+
+	definitions := make(map[string]RuleSetDefinition)
+	for _, ruleset := range r.Spec.Rulesets {
+		definitions[ruleset.Name] = ruleset.RuleSetDefinition
+	}
+	for _, variant := range enforcementVariants(definitions) {
+		warnings = append(warnings, NewCodedWarning("enforcement-variant", "rulesets %s and %s only differ by their enforcement (leftover dry-run copy?) (check repository filename %s)", variant[0], variant[1], filename))
+	}
+
+	if strings.ContainsAny(r.Name[:1], ".-_") || strings.ContainsAny(r.Name[len(r.Name)-1:], ".-_") {
+		return fmt.Errorf("invalid name: %s must not start or end with '.', '-' or '_' (check repository filename %s)", r.Name, filename), warnings
 	}
 
 	if utils.GithubAnsiString(r.Name) != r.Name {
-		return fmt.Errorf("invalid name: %s will be changed to %s (check repository filename %s)", r.Name, utils.GithubAnsiString(r.Name), filename)
+		return fmt.Errorf("invalid name: %s will be changed to %s (check repository filename %s)", r.Name, utils.GithubAnsiString(r.Name), filename), warnings
 	}
 
-	return nil
+	return nil, warnings
+}
+
+/*
+ * RequiresIssueTemplate warns when the issues are enabled but the repository
+ * doesn't ship an issue template. The repository contents are expected in a
+ * directory named after the repository, next to its definition file: if
+ * the contents are not available, nothing is checked
+ */
+func (r *Repository) RequiresIssueTemplate(fs billy.Filesystem) []Warning {
+	warnings := []Warning{}
+	if r.Spec.HasIssues != nil && !*r.Spec.HasIssues {
+		return warnings
+	}
+
+	contents := filepath.Join(r.DirectoryPath, r.Name)
+	if exist, err := utils.Exists(fs, contents); err != nil || !exist {
+		return warnings
+	}
+	if exist, err := utils.Exists(fs, filepath.Join(contents, ".github", "ISSUE_TEMPLATE")); err == nil && !exist {
+		warnings = append(warnings, NewCodedWarning("missing-issue-template", "repository %s has issues enabled but no .github/ISSUE_TEMPLATE (check repository filename %s)", r.Name, r.ExpectedPath(r.DirectoryPath)))
+	}
+	return warnings
+}
+
+/*
+ * adminUsers returns all the users that are granted admin access to the
+ * repository, i.e. the owners and members of the owning team
+ */
+func (r *Repository) adminUsers(teams map[string]*Team) []string {
+	admins := []string{}
+	if r.Owner == nil {
+		return admins
+	}
+	if team, ok := teams[*r.Owner]; ok {
+		admins = append(admins, team.Spec.Owners...)
+		admins = append(admins, team.Spec.Members...)
+	}
+	return admins
+}
+
+type FeatureDependency struct {
+	Feature  string
+	Requires string
+	Warning  bool // only warn (instead of error) when the dependency is not met
+}
+
+/*
+ * FeatureDependencies lists the repository features depending on another
+ * feature. The features states are resolved by repositoryFeatures
+ */
+var FeatureDependencies = []FeatureDependency{
+	{Feature: "merge_queue", Requires: "allow_auto_merge"},
+	{Feature: "dependabot_security_updates", Requires: "dependabot_alerts"},
+	{Feature: "has_discussions", Requires: "has_issues", Warning: true},
+}
+
+/*
+ * repositoryFeatures returns the state of a repository feature:
+ * nil if the feature is not managed, else enabled or explicitly disabled
+ */
+var repositoryFeatures = map[string]func(r *Repository) *bool{
+	"allow_auto_merge": func(r *Repository) *bool {
+		return &r.Spec.AllowAutoMerge
+	},
+	"merge_queue": func(r *Repository) *bool {
+		enabled := false
+		for _, ruleset := range r.Spec.Rulesets {
+			for _, rule := range ruleset.Rules {
+				if rule.Ruletype == "merge_queue" {
+					enabled = true
+				}
+			}
+		}
+		return &enabled
+	},
+	"has_issues":                  func(r *Repository) *bool { return r.Spec.HasIssues },
+	"has_discussions":             func(r *Repository) *bool { return r.Spec.HasDiscussions },
+	"dependabot_alerts":           func(r *Repository) *bool { return r.Spec.DependabotAlerts },
+	"dependabot_security_updates": func(r *Repository) *bool { return r.Spec.DependabotSecurityUpdates },
+}
+
+/*
+ * ValidateFeatureDependencies checks the FeatureDependencies: it returns an
+ * error for each enabled feature whose required feature is explicitly
+ * disabled, or a warning if the dependency is flagged as Warning
+ */
+func (r *Repository) ValidateFeatureDependencies() ([]error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+	for _, dep := range FeatureDependencies {
+		feature, ok := repositoryFeatures[dep.Feature]
+		if !ok {
+			errors = append(errors, fmt.Errorf("unknown feature %s", dep.Feature))
+			continue
+		}
+		requires, ok := repositoryFeatures[dep.Requires]
+		if !ok {
+			errors = append(errors, fmt.Errorf("unknown feature %s", dep.Requires))
+			continue
+		}
+		enabled := feature(r)
+		required := requires(r)
+		if enabled == nil || !*enabled || required == nil || *required {
+			continue
+		}
+		if dep.Warning {
+			warnings = append(warnings, NewCodedWarning("feature-dependency", "%s is enabled but %s is disabled", dep.Feature, dep.Requires))
+		} else {
+			errors = append(errors, fmt.Errorf("invalid %s: %s must be enabled to enable %s", dep.Feature, dep.Requires, dep.Feature))
+		}
+	}
+	return errors, warnings
+}
+
+/*
+ * visibility returns "public" or "private"
+ */
+func (r *Repository) visibility() string {
+	if r.Spec.IsPublic {
+		return "public"
+	}
+	return "private"
+}
+
+/*
+ * mergeMethodEnabled returns true if at least one merge method is enabled
+ * (an unset merge method uses the Github default, i.e. enabled)
+ */
+func (r *Repository) mergeMethodEnabled() bool {
+	for _, method := range []*bool{r.Spec.AllowMergeCommit, r.Spec.AllowSquashMerge, r.Spec.AllowRebaseMerge} {
+		if method == nil || *method {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * ExpectedPath returns the filename where the repository definition
+ * should live, given the directory of its owning team
+ */
+func (r *Repository) ExpectedPath(teamDir string) string {
+	if r.Filename != "" {
+		return filepath.Join(teamDir, filepath.Base(r.Filename))
+	}
+	return filepath.Join(teamDir, r.Name+".yaml")
+}
+
+/*
+ * CheckPlacement warns if the repository file is not located in the
+ * directory of its owning team (for example after a team refactoring)
+ */
+func (r *Repository) CheckPlacement(teamDirname string, teams map[string]*Team) []Warning {
+	warnings := []Warning{}
+	if r.Spec.Owner == "" || r.Archived {
+		return warnings
+	}
+	if _, ok := teams[r.Spec.Owner]; !ok {
+		return warnings
+	}
+
+	expected := filepath.Dir(r.ExpectedPath(teamDirectoryPath(teamDirname, r.Spec.Owner, teams)))
+	if filepath.Clean(r.DirectoryPath) != expected {
+		warnings = append(warnings, withFile(r.definitionFile(), NewCodedWarning("misplaced-repo", "repository %s is defined in %s but its declared owning team %s directory is %s", r.Name, r.DirectoryPath, r.Spec.Owner, expected)))
+	}
+	return warnings
+}
+
+/*
+ * teamDirectoryPath rebuilds the directory of a team based on its
+ * parent teams hierarchy
+ */
+func teamDirectoryPath(teamDirname string, teamname string, teams map[string]*Team) string {
+	path := []string{teamname}
+	visited := map[string]bool{teamname: true}
+	current := teams[teamname]
+	for current != nil && current.ParentTeam != nil && !visited[*current.ParentTeam] {
+		visited[*current.ParentTeam] = true
+		path = append([]string{*current.ParentTeam}, path...)
+		current = teams[*current.ParentTeam]
+	}
+	return filepath.Join(append([]string{teamDirname}, path...)...)
+}
+
+/*
+ * ValidateOrgLimits checks that the number of (non archived) private
+ * repositories doesn't exceed what the Github plan allows.
+ * If maxPrivate is 0, there is no limit
+ */
+func ValidateOrgLimits(repos map[string]*Repository, maxPrivate int) []error {
+	errors := []error{}
+	if maxPrivate == 0 {
+		return errors
+	}
+
+	nbPrivate := 0
+	for _, repo := range repos {
+		if !repo.Archived && !repo.Spec.IsPublic {
+			nbPrivate++
+		}
+	}
+	if nbPrivate > maxPrivate {
+		errors = append(errors, fmt.Errorf("too many private repositories: %d defined but the organization plan allows only %d", nbPrivate, maxPrivate))
+	}
+	return errors
+}
+
+/*
+ * Clone returns a deep copy of the repository, so it can be modified
+ * without affecting the original one
+ */
+func (r *Repository) Clone() *Repository {
+	c := *r
+	c.Include = append([]string(nil), r.Include...)
+	c.Spec.Writers = append([]string(nil), r.Spec.Writers...)
+	c.Spec.Readers = append([]string(nil), r.Spec.Readers...)
+	c.Spec.TriageTeams = append([]string(nil), r.Spec.TriageTeams...)
+	c.Spec.MaintainTeams = append([]string(nil), r.Spec.MaintainTeams...)
+	c.Spec.ExternalUserReaders = append([]string(nil), r.Spec.ExternalUserReaders...)
+	c.Spec.ExternalUserWriters = append([]string(nil), r.Spec.ExternalUserWriters...)
+	c.Spec.Topics = append([]string(nil), r.Spec.Topics...)
+	c.Spec.ProtectedTags = append([]string(nil), r.Spec.ProtectedTags...)
+	if r.Spec.Properties != nil {
+		c.Spec.Properties = make(map[string]string, len(r.Spec.Properties))
+		for k, v := range r.Spec.Properties {
+			c.Spec.Properties[k] = v
+		}
+	}
+	if r.Spec.Rulesets != nil {
+		c.Spec.Rulesets = make([]RepositoryRuleSet, len(r.Spec.Rulesets))
+		for i, rs := range r.Spec.Rulesets {
+			c.Spec.Rulesets[i] = RepositoryRuleSet{
+				RuleSetDefinition: rs.RuleSetDefinition.clone(),
+				Name:              rs.Name,
+				Note:              rs.Note,
+			}
+		}
+	}
+	c.Spec.AllowMergeCommit = cloneBool(r.Spec.AllowMergeCommit)
+	c.Spec.AllowSquashMerge = cloneBool(r.Spec.AllowSquashMerge)
+	c.Spec.AllowRebaseMerge = cloneBool(r.Spec.AllowRebaseMerge)
+	c.Spec.HasIssues = cloneBool(r.Spec.HasIssues)
+	c.Spec.HasDiscussions = cloneBool(r.Spec.HasDiscussions)
+	c.Spec.WebCommitSignoffRequired = cloneBool(r.Spec.WebCommitSignoffRequired)
+	c.Spec.DependabotAlerts = cloneBool(r.Spec.DependabotAlerts)
+	c.Spec.DependabotSecurityUpdates = cloneBool(r.Spec.DependabotSecurityUpdates)
+	if r.Owner != nil {
+		owner := *r.Owner
+		c.Owner = &owner
+	}
+	if r.ExternalUserWritersExpiry != nil {
+		c.ExternalUserWritersExpiry = make(map[string]string, len(r.ExternalUserWritersExpiry))
+		for k, v := range r.ExternalUserWritersExpiry {
+			c.ExternalUserWritersExpiry[k] = v
+		}
+	}
+	if r.DeprecatedFields != nil {
+		c.DeprecatedFields = make(map[string]string, len(r.DeprecatedFields))
+		for k, v := range r.DeprecatedFields {
+			c.DeprecatedFields[k] = v
+		}
+	}
+	return &c
+}
+
+/*
+ * Checksum returns a stable hash of the repository definition, used to
+ * detect semantic changes. Lists whose order doesn't matter are sorted
+ * first, and the includes (already merged) and DirectoryPath are ignored.
+ * The owning team and the external writers expiries are taken into account.
+ */
+func (r *Repository) Checksum() string {
+	c := r.Clone()
+	c.Include = nil
+	sort.Strings(c.Spec.Writers)
+	sort.Strings(c.Spec.Readers)
+	sort.Strings(c.Spec.TriageTeams)
+	sort.Strings(c.Spec.MaintainTeams)
+	sort.Strings(c.Spec.ExternalUserReaders)
+	sort.Strings(c.Spec.ExternalUserWriters)
+	sort.Strings(c.Spec.Topics)
+	sort.Strings(c.Spec.ProtectedTags)
+	sort.Slice(c.Spec.Rulesets, func(i, j int) bool {
+		return c.Spec.Rulesets[i].Name < c.Spec.Rulesets[j].Name
+	})
+	for i := range c.Spec.Rulesets {
+		rs := &c.Spec.Rulesets[i]
+		rs.Note = ""
+		sort.Strings(rs.Conditions.Include)
+		sort.Strings(rs.Conditions.Exclude)
+		sort.Slice(rs.BypassApps, func(i, j int) bool {
+			return rs.BypassApps[i].AppName < rs.BypassApps[j].AppName
+		})
+		sort.Slice(rs.Rules, func(i, j int) bool {
+			return rs.Rules[i].Ruletype < rs.Rules[j].Ruletype
+		})
+		for k := range rs.Rules {
+			sort.Strings(rs.Rules[k].Parameters.RequiredStatusChecks)
+			sort.Strings(rs.Rules[k].Parameters.RequiredReviewers)
+		}
+	}
+
+	// Owner and the external writers expiries are not marshalled with the
+	// repository (yaml:"-") but are part of its definition
+	content, err := yaml.Marshal(struct {
+		Repository                *Repository       `yaml:"repository"`
+		Owner                     *string           `yaml:"owner,omitempty"`
+		ExternalUserWritersExpiry map[string]string `yaml:"externalUserWritersExpiry,omitempty"`
+	}{c, c.Owner, c.ExternalUserWritersExpiry})
+	if err != nil {
+		// cannot happen with plain structs
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+ * DetectPermissionConflicts warns when a team and one of its parent teams
+ * (teamHierarchy is a map of team name -> parent team name) are granted
+ * different access levels on the repository. Since child teams inherit
+ * their parent's permissions, the effective permission can be surprising.
+ */
+func (r *Repository) DetectPermissionConflicts(teamHierarchy map[string]string) []Warning {
+	warnings := []Warning{}
+
+	access := make(map[string]string)
+	for _, reader := range r.Spec.Readers {
+		access[reader] = "read"
+	}
+	for _, triager := range r.Spec.TriageTeams {
+		access[triager] = "triage"
+	}
+	for _, writer := range r.Spec.Writers {
+		access[writer] = "write"
+	}
+	for _, maintainer := range r.Spec.MaintainTeams {
+		access[maintainer] = "maintain"
+	}
+	if r.Owner != nil {
+		access[*r.Owner] = "admin"
+	}
+
+	teamnames := make([]string, 0, len(access))
+	for team := range access {
+		teamnames = append(teamnames, team)
+	}
+	sort.Strings(teamnames)
+
+	for _, team := range teamnames {
+		permission := access[team]
+		visited := map[string]bool{team: true}
+		parent, ok := teamHierarchy[team]
+		for ok && !visited[parent] {
+			visited[parent] = true
+			if parentPermission, granted := access[parent]; granted && parentPermission != permission {
+				warnings = append(warnings, NewCodedWarning("parent-team-access", "repository %s: team %s has %s access but its parent team %s has %s access", r.Name, team, permission, parent, parentPermission))
+			}
+			parent, ok = teamHierarchy[parent]
+		}
+	}
+	return warnings
+}
+
+/*
+ * ReposMissingRuleset returns the (sorted) names of the repositories matching
+ * the match function that don't define an inline ruleset named requiredRulesetName
+ */
+func ReposMissingRuleset(repos map[string]*Repository, requiredRulesetName string, match func(*Repository) bool) []string {
+	missing := []string{}
+	for reponame, repo := range repos {
+		if !match(repo) {
+			continue
+		}
+		found := false
+		for _, ruleset := range repo.Spec.Rulesets {
+			if ruleset.Name == requiredRulesetName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, reponame)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// maximum length of a Github repository name
+const RepositoryNameMaxLength = 100
+
+/*
+ * RepositoryDefaultReservedNames are the names a repository cannot have by
+ * default: the paths Github routes at the organization level
+ * (github.com/<org>/<name>), a repository named after them would be
+ * rejected or shadowed. See ValidationOptions.RepositoryReservedNames
+ */
+var RepositoryDefaultReservedNames = []string{
+	"followers",
+	"following",
+	"people",
+	"projects",
+	"repositories",
+	"settings",
+	"sponsoring",
+	"teams",
+}
+
+/*
+ * ValidateRename checks that repoName can be renamed to newName: the new name
+ * must be a valid Github repository name, and must not collide (case
+ * insensitively) with an existing repository or another pending rename,
+ * nor be a reserved name
+ */
+func ValidateRename(repos map[string]*Repository, repoName, newName string, opts ValidationOptions) []error {
+	errors := []error{}
+
+	if _, ok := repos[repoName]; !ok {
+		errors = append(errors, fmt.Errorf("repository %s doesn't exist", repoName))
+	}
+	if newName == "" {
+		errors = append(errors, fmt.Errorf("new name for repository %s is empty", repoName))
+		return errors
+	}
+	if len(newName) > RepositoryNameMaxLength {
+		errors = append(errors, fmt.Errorf("invalid new name: %s must not exceed %d characters", newName, RepositoryNameMaxLength))
+	}
+	if utils.GithubAnsiString(newName) != newName {
+		errors = append(errors, fmt.Errorf("invalid new name: %s will be changed to %s", newName, utils.GithubAnsiString(newName)))
+	}
+	if strings.ContainsAny(newName[:1], ".-_") || strings.ContainsAny(newName[len(newName)-1:], ".-_") {
+		errors = append(errors, fmt.Errorf("invalid new name: %s must not start or end with '.', '-' or '_'", newName))
+	}
+	if strings.HasSuffix(strings.ToLower(newName), ".git") {
+		errors = append(errors, fmt.Errorf("invalid new name: %s must not end with .git", newName))
+	}
+	if opts.repositoryReservedName(newName) != "" {
+		errors = append(errors, fmt.Errorf("invalid new name: %s is a reserved name", newName))
+	}
+
+	reponames := SortedRepositoryNames(repos)
+
+	for _, reponame := range reponames {
+		if reponame == repoName {
+			continue
+		}
+		if strings.EqualFold(reponame, newName) {
+			errors = append(errors, fmt.Errorf("cannot rename %s to %s: repository %s already exists", repoName, newName, reponame))
+		}
+		if renameTo := repos[reponame].RenameTo; renameTo != "" && strings.EqualFold(renameTo, newName) {
+			errors = append(errors, fmt.Errorf("cannot rename %s to %s: repository %s is already being renamed to %s", repoName, newName, reponame, renameTo))
+		}
+	}
+	return errors
+}
+
+/*
+ * SortedRepositoryNames returns the repositories names sorted, to iterate
+ * over the repositories in a deterministic order (i.e. for plan output)
+ */
+func SortedRepositoryNames(repos map[string]*Repository) []string {
+	reponames := make([]string, 0, len(repos))
+	for reponame := range repos {
+		reponames = append(reponames, reponame)
+	}
+	sort.Strings(reponames)
+	return reponames
+}
+
+/*
+ * ArchivalConsistent checks that the repository archival state on Github
+ * matches where the repository is defined (in the archived directory or not)
+ */
+func (r *Repository) ArchivalConsistent(remoteArchived bool) error {
+	if r.ExpectedArchived && !remoteArchived {
+		return fmt.Errorf("repository %s is in the archived directory but is not archived on Github", r.Name)
+	}
+	if !r.ExpectedArchived && remoteArchived {
+		return fmt.Errorf("repository %s is archived on Github but is not in the archived directory", r.Name)
+	}
+	return nil
+}
+
+/*
+ * MarkForDeletion flags the repository to be deleted (and not only archived)
+ * on the next apply
+ */
+func (r *Repository) MarkForDeletion() {
+	r.Deleted = true
+}
+
+/*
+ * ReposPendingDeletion returns the repositories flagged for deletion,
+ * sorted by name, i.e. to ask for a confirmation before applying
+ */
+func ReposPendingDeletion(repos map[string]*Repository) []*Repository {
+	pending := []*Repository{}
+	for _, repo := range repos {
+		if repo.Deleted {
+			pending = append(pending, repo)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Name < pending[j].Name
+	})
+	return pending
+}
+
+/*
+ * ValidatePublicApproval is an optional validator: if the organization
+ * default visibility is "private", every (non archived) public repository
+ * must be part of the approvedPublic set
+ */
+func ValidatePublicApproval(repos map[string]*Repository, orgDefaultVisibility string, approvedPublic map[string]bool) []error {
+	errors := []error{}
+	if orgDefaultVisibility != "private" {
+		return errors
+	}
+
+	reponames := SortedRepositoryNames(repos)
+
+	for _, reponame := range reponames {
+		repo := repos[reponame]
+		if repo.Archived || !repo.Spec.IsPublic {
+			continue
+		}
+		if !approvedPublic[reponame] {
+			errors = append(errors, fmt.Errorf("repository %s is public but has not been approved to be public (organization default visibility is private)", reponame))
+		}
+	}
+	return errors
+}
+
+/*
+ * ReconciliationOrder returns the repositories in the order they should be
+ * reconciled: renames first, then creations/updates, then archives, and
+ * finally deletions. Repositories of the same kind are sorted by name
+ */
+func ReconciliationOrder(repos map[string]*Repository) []*Repository {
+	rank := func(r *Repository) int {
+		switch {
+		case r.Deleted:
+			return 3
+		case r.RenameTo != "":
+			return 0
+		case r.Archived:
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	ordered := make([]*Repository, 0, len(repos))
+	for _, repo := range repos {
+		ordered = append(ordered, repo)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if rank(ordered[i]) != rank(ordered[j]) {
+			return rank(ordered[i]) < rank(ordered[j])
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	return ordered
+}
+
+/*
+ * ValidateTeamsNonEmpty warns for each repository granted to a team
+ * without any member (no one would get access through this grant)
+ */
+func ValidateTeamsNonEmpty(repos map[string]*Repository, teams map[string]*Team) []Warning {
+	warnings := []Warning{}
+
+	reponames := SortedRepositoryNames(repos)
+
+	for _, reponame := range reponames {
+		repo := repos[reponame]
+		grants := append([]string{}, repo.Spec.Writers...)
+		grants = append(grants, repo.Spec.Readers...)
+		grants = append(grants, repo.Spec.TriageTeams...)
+		grants = append(grants, repo.Spec.MaintainTeams...)
+		if repo.Owner != nil {
+			grants = append(grants, *repo.Owner)
+		}
+		for _, teamname := range grants {
+			if team, ok := teams[teamname]; ok && !team.HasMembers() {
+				warnings = append(warnings, NewCodedWarning("empty-team-grant", "repository %s is granted to team %s which has no member", reponame, teamname))
+			}
+		}
+	}
+	return warnings
+}
+
+/*
+ * describeDefinition returns where the repository is defined, telling
+ * apart the archived and the active definitions
+ */
+func (r *Repository) describeDefinition() string {
+	if r.Archived {
+		return fmt.Sprintf("archived definition %s", r.definitionFile())
+	}
+	return fmt.Sprintf("active definition %s", r.definitionFile())
+}
+
+/*
+ * definitionFile returns the file the repository is defined in
+ */
+func (r *Repository) definitionFile() string {
+	if r.Filename != "" {
+		return r.Filename
+	}
+	return r.ExpectedPath(r.DirectoryPath)
+}
+
+/*
+ * validateGlobalUniqueness is a final pass checking that repository names
+ * are uniq across the archived and active definitions. Github repository
+ * names are case insensitive, so the check is case insensitive
+ */
+func validateGlobalUniqueness(repos map[string]*Repository) []error {
+	errors := []error{}
+
+	reponames := SortedRepositoryNames(repos)
+
+	seen := make(map[string]*Repository)
+	for _, reponame := range reponames {
+		repo := repos[reponame]
+		lower := strings.ToLower(reponame)
+		if other, ok := seen[lower]; ok {
+			errors = append(errors, withFile(repo.definitionFile(), fmt.Errorf("Repository %s and %s have the same name for Github (names are case insensitive): check %s and %s", other.Name, repo.Name, other.describeDefinition(), repo.describeDefinition())))
+			continue
+		}
+		seen[lower] = repo
+	}
+	return errors
+}
+
+/*
+ * ProtectedBranchPatterns returns the (sorted) union of the branch patterns
+ * included by the repository inline (branch) rulesets. ~DEFAULT_BRANCH is
+ * resolved to the repository default branch ("main" if not set)
+ */
+func (r *Repository) ProtectedBranchPatterns() []string {
+	patterns := make(map[string]bool)
+	for _, ruleset := range r.Spec.Rulesets {
+		if ruleset.target() != "branch" {
+			continue
+		}
+		for _, include := range ruleset.Conditions.Include {
+			if include == "~DEFAULT_BRANCH" {
+				include = r.defaultBranch()
+			}
+			patterns[include] = true
+		}
+	}
+
+	result := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		result = append(result, pattern)
+	}
+	sort.Strings(result)
+	return result
+}
+
+/*
+ * RulesetsForBranch returns the repository inline rulesets applying to the branch
+ * (~DEFAULT_BRANCH is resolved to the repository default branch, "main" if not set)
+ */
+func (r *Repository) RulesetsForBranch(branch string) []RepositoryRuleSet {
+	rulesets := []RepositoryRuleSet{}
+	for _, ruleset := range r.Spec.Rulesets {
+		if ruleset.matchesBranch(branch, r.defaultBranch()) {
+			rulesets = append(rulesets, ruleset)
+		}
+	}
+	return rulesets
+}
+
+/*
+ * grantedTeams returns the (internal) teams granted access to the repository,
+ * the owner excepted
+ */
+func (r *Repository) grantedTeams() []string {
+	granted := append([]string{}, r.Spec.Writers...)
+	granted = append(granted, r.Spec.Readers...)
+	granted = append(granted, r.Spec.TriageTeams...)
+	return append(granted, r.Spec.MaintainTeams...)
+}
+
+/*
+ * administratorTeams returns the teams able to manage the repository
+ * settings: the owner (admin) and the maintain teams
+ */
+func (r *Repository) administratorTeams() []string {
+	administrators := append([]string{}, r.Spec.MaintainTeams...)
+	if r.Owner != nil {
+		administrators = append(administrators, *r.Owner)
+	}
+	return administrators
+}
+
+/*
+ * anyTeamWithMembers returns true if at least one of the teams has members
+ * (a team without member doesn't give access to anyone)
+ */
+func anyTeamWithMembers(teamnames []string, teams map[string]*Team) bool {
+	for _, teamname := range teamnames {
+		if team, ok := teams[teamname]; ok && team.HasMembers() {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * defaultBranch returns the repository default branch ("main" if not set)
+ */
+func (r *Repository) defaultBranch() string {
+	if r.Spec.DefaultBranch == "" {
+		return "main"
+	}
+	return r.Spec.DefaultBranch
+}
+
+/*
+ * DetectRulesetContradictions warns when an (org-applied) ruleset and a
+ * repository inline ruleset target a common branch with the same rule type
+ * but different parameters: Github enforces the stricter one, which
+ * muddies the intent of the repository ruleset
+ */
+func (r *Repository) DetectRulesetContradictions(applicable []*RuleSet) []Warning {
+	warnings := []Warning{}
+	defaultBranch := r.defaultBranch()
+
+	for _, ruleset := range applicable {
+		for _, inline := range r.Spec.Rulesets {
+			if !rulesetsOverlap(&ruleset.Spec, &inline.RuleSetDefinition, defaultBranch) {
+				continue
+			}
+			for _, orgRule := range ruleset.Spec.Rules {
+				for _, repoRule := range inline.Rules {
+					if orgRule.Ruletype != repoRule.Ruletype {
+						continue
+					}
+					if !CompareRulesetParameters(orgRule.Ruletype, orgRule.Parameters, repoRule.Parameters) {
+						warnings = append(warnings, NewCodedWarning("overlapping-rulesets", "repository %s ruleset %s and ruleset %s both apply %s to the same branches with different parameters: the stricter one wins", r.Name, inline.Name, ruleset.Name, orgRule.Ruletype))
+					}
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+/*
+ * rulesetsOverlap returns true if both rulesets target at least one common
+ * branch, among the default branch and the branches explicitly included
+ */
+func rulesetsOverlap(left *RuleSetDefinition, right *RuleSetDefinition, defaultBranch string) bool {
+	candidates := []string{defaultBranch}
+	for _, definition := range []*RuleSetDefinition{left, right} {
+		for _, include := range definition.Conditions.Include {
+			if !strings.HasPrefix(include, "~") {
+				candidates = append(candidates, include)
+			}
+		}
+	}
+	for _, branch := range candidates {
+		if left.matchesBranch(branch, defaultBranch) && right.matchesBranch(branch, defaultBranch) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * DiffRuleSets compares 2 lists of repository rulesets and returns the
+ * (sorted) names of the rulesets added, removed and changed
+ */
+func DiffRuleSets(before, after []RepositoryRuleSet) (added, removed, changed []string) {
+	added = []string{}
+	removed = []string{}
+	changed = []string{}
+
+	beforeRulesets := make(map[string]RepositoryRuleSet)
+	for _, ruleset := range before {
+		beforeRulesets[ruleset.Name] = ruleset
+	}
+	afterRulesets := make(map[string]RepositoryRuleSet)
+	for _, ruleset := range after {
+		afterRulesets[ruleset.Name] = ruleset
+	}
+
+	for name, ruleset := range afterRulesets {
+		previous, ok := beforeRulesets[name]
+		if !ok {
+			added = append(added, name)
+		} else if !CompareRuleSetDefinitions(previous.RuleSetDefinition, ruleset.RuleSetDefinition) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range beforeRulesets {
+		if _, ok := afterRulesets[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// conventional order of the top level fields of a repository file
+var repositoryFieldsOrder = []string{"apiVersion", "kind", "name", "spec", "archived", "archivedReason", "renameTo"}
+
+// spec fields whose default value is false
+var repositoryDefaultFalseFields = map[string]bool{
+	"public":                 true,
+	"allow_auto_merge":       true,
+	"delete_branch_on_merge": true,
+	"allow_update_branch":    true,
+}
+
+/*
+ * LintRepositoryFile parses a repository file and returns stylistic warnings
+ * (fields order, empty lists, booleans set to their default value).
+ * It is advisory only and doesn't validate the repository
+ */
+func LintRepositoryFile(fs billy.Filesystem, filename string) []Warning {
+	warnings := []Warning{}
+
+	filecontent, err := utils.ReadFile(fs, filename)
+	if err != nil {
+		return append(warnings, NewCodedWarning("lint-parse", "%v", err))
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(filecontent, &document); err != nil {
+		return append(warnings, NewCodedWarning("lint-parse", "not able to parse %s: %v", filename, err))
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return warnings
+	}
+	root := document.Content[0]
+
+	fieldRank := make(map[string]int)
+	for i, field := range repositoryFieldsOrder {
+		fieldRank[field] = i
+	}
+	lastRank := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		rank, known := fieldRank[key]
+		if !known {
+			continue
+		}
+		if rank < lastRank {
+			warnings = append(warnings, NewCodedWarning("field-order", "field %s is not in the conventional order (%s) (line %d of %s)", key, strings.Join(repositoryFieldsOrder, ", "), root.Content[i].Line, filename))
+		}
+		lastRank = rank
+
+		if key != "spec" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		spec := root.Content[i+1]
+		for j := 0; j+1 < len(spec.Content); j += 2 {
+			field := spec.Content[j].Value
+			value := spec.Content[j+1]
+			if value.Kind == yaml.SequenceNode && len(value.Content) == 0 {
+				warnings = append(warnings, NewCodedWarning("empty-list", "spec.%s is an empty list and can be removed (line %d of %s)", field, value.Line, filename))
+			}
+			if repositoryDefaultFalseFields[field] && value.Kind == yaml.ScalarNode && value.Value == "false" {
+				warnings = append(warnings, NewCodedWarning("default-value", "spec.%s is set to its default value (false) and can be removed (line %d of %s)", field, value.Line, filename))
+			}
+		}
+	}
+	return warnings
+}
+
+/*
+ * crossValidateRepositories is a final pass validating the repositories
+ * against each other (once all are loaded)
+ */
+func crossValidateRepositories(repos map[string]*Repository, teams map[string]*Team) ([]error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+
+	reponames := SortedRepositoryNames(repos)
+
+	for _, reponame := range reponames {
+		repo := repos[reponame]
+		if repo.Archived && len(repo.grantedTeams()) > 0 {
+			warnings = append(warnings, withFile(repo.definitionFile(), NewCodedWarning("archived-grants", "archived repository %s is still granted to teams (writers/readers/triage/maintain): these grants are useless (check %s)", reponame, repo.describeDefinition())))
+		}
+		if repo.Archived && len(repo.Spec.ExternalUserWriters) > 0 {
+			errors = append(errors, withFile(repo.definitionFile(), fmt.Errorf("archived repository %s grants write access to external users %s (check %s)", reponame, strings.Join(repo.Spec.ExternalUserWriters, ", "), repo.describeDefinition())))
+		}
+		if repo.Archived && len(repo.Spec.ExternalUserReaders) > 0 {
+			warnings = append(warnings, withFile(repo.definitionFile(), NewCodedWarning("archived-grants", "archived repository %s is still granted to external users %s (check %s)", reponame, strings.Join(repo.Spec.ExternalUserReaders, ", "), repo.describeDefinition())))
+		}
+		if !repo.Archived && (len(repo.Spec.ExternalUserWriters) > 0 || len(repo.Spec.ExternalUserReaders) > 0) && !anyTeamWithMembers(append(repo.grantedTeams(), repo.administratorTeams()...), teams) {
+			warnings = append(warnings, withFile(repo.definitionFile(), NewCodedWarning("external-only-access", "repository %s has no internal team with members: only external users can access it (check %s)", reponame, repo.describeDefinition())))
+		}
+		if repo.RenameTo != "" {
+			if target, ok := repos[repo.RenameTo]; ok && target.RenameTo != "" {
+				errors = append(errors, withFile(repo.definitionFile(), fmt.Errorf("ambiguous rename chain: %s is renamed to %s, which is itself renamed to %s (check %s and %s)", reponame, repo.RenameTo, target.RenameTo, repo.describeDefinition(), target.describeDefinition())))
+			}
+		}
+	}
+	return errors, warnings
 }
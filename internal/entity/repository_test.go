@@ -1,7 +1,12 @@
 package entity
 
 import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
@@ -62,12 +67,12 @@ name: repo1
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, 0, len(errs))
 		assert.Equal(t, 0, len(warns))
 		assert.NotNil(t, repos)
@@ -89,12 +94,12 @@ name: repo2
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 1)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -118,12 +123,12 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 1)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -147,12 +152,12 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 1)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -175,18 +180,99 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, repos)
 		assert.Equal(t, len(repos), 1)
 	})
 
+	t.Run("not happy path: archived and active repos differing only by case", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "archived/Repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: Repo1
+archivedReason: renamed
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "archived definition archived/Repo1.yaml")
+	})
+
+	t.Run("not happy path: archived repo defined in a .yaml and a .yml file", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		for _, filename := range []string{"archived/repo2.yaml", "archived/repo2.yml"} {
+			err := utils.WriteFile(fs, filename, []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+archivedReason: deprecated
+`), 0644)
+			assert.Nil(t, err)
+		}
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "Repository repo2 defined in 2 places")
+		assert.NotNil(t, repos["repo2"])
+	})
+
+	t.Run("happy path: archived repo without reason is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "archived/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(repos))
+	})
+
 	t.Run("happy path: archived repo", func(t *testing.T) {
 		// create a new user
 		fs := memfs.New()
@@ -196,6 +282,7 @@ spec:
 apiVersion: v1
 kind: Repository
 name: repo1
+archivedReason: replaced by repo2
 `), 0644)
 		assert.Nil(t, err)
 		users, errs, warns := ReadUserDirectory(fs, "users")
@@ -203,15 +290,2251 @@ name: repo1
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 
-		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, repos)
 		assert.Equal(t, len(repos), 1)
+		assert.Equal(t, "replaced by repo2", repos["repo1"].ArchivedReason)
+		assert.True(t, repos["repo1"].ExpectedArchived)
+		assert.Nil(t, repos["repo1"].ArchivalConsistent(true))
+		assert.NotNil(t, repos["repo1"].ArchivalConsistent(false))
+	})
+
+	t.Run("happy path: owning team listed as writer is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+  - team1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(repos))
+	})
+
+	t.Run("not happy path: auto merge without any merge method", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  allow_auto_merge: true
+  allow_merge_commit: false
+  allow_squash_merge: false
+  allow_rebase_merge: false
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("happy path: merge commit format with merge commits disabled is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  allow_merge_commit: false
+  merge_commit_title: PR_TITLE
+  merge_commit_message: PR_BODY
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+	})
+
+	t.Run("not happy path: invalid merge commit title", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  merge_commit_title: COMMIT_MESSAGES
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("happy path: evaluate ruleset without note is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  rulesets:
+  - name: noted
+    enforcement: evaluate
+    note: trying to forbid deletion
+    rules:
+    - ruletype: deletion
+  - name: unnoted
+    enforcement: evaluate
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, "trying to forbid deletion", repos["repo1"].Spec.Rulesets[0].Note)
+	})
+
+	t.Run("happy path: owning team injected as required reviewer", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  rulesets:
+  - name: review
+    enforcement: active
+    rules:
+    - ruletype: pull_request
+      parameters:
+        requiredApprovingReviewCount: 1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{OwnerRequiredReviewer: true})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, []string{"team1"}, repos["repo1"].Spec.Rulesets[0].Rules[0].Parameters.RequiredReviewers)
+	})
+
+	t.Run("happy path: web commit signoff required", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  web_commit_signoff_required: true
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		assert.True(t, *repo.Spec.WebCommitSignoffRequired)
+		assert.True(t, *repo.Clone().Spec.WebCommitSignoffRequired)
+
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  web_commit_signoff_required: sometimes
+`), 0644)
+		assert.Nil(t, err)
+		_, err = NewRepository(fs, "teams/team1/repo2.yaml")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("happy path: dependabot alerts", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  dependabot_alerts: true
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		assert.True(t, *repo.Spec.DependabotAlerts)
+		assert.True(t, *repo.Clone().Spec.DependabotAlerts)
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(warns))
+	})
+
+	t.Run("not happy path: dependabot security updates without alerts", func(t *testing.T) {
+		enabled, disabled := true, false
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.DependabotSecurityUpdates = &enabled
+		repo.Spec.DependabotAlerts = &disabled
+
+		err, _ := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "invalid dependabot_security_updates")
+
+		// valid, but security updates are not applied by Goliac
+		repo.Spec.DependabotAlerts = &enabled
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "dependabot_security_updates is not applied by Goliac")
+	})
+
+	t.Run("happy path: signatures required on the default branch only", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  default_branch: main
+  rulesets:
+  - name: signed
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, []string{"main"}, repos["repo1"].ProtectedBranchPatterns())
+
+		repos["repo1"].Spec.DefaultBranch = "develop"
+		assert.Equal(t, []string{"develop"}, repos["repo1"].ProtectedBranchPatterns())
+
+		// ~DEFAULT_BRANCH is resolved like everywhere else when no default branch is set
+		repos["repo1"].Spec.DefaultBranch = ""
+		assert.Equal(t, []string{"main"}, repos["repo1"].ProtectedBranchPatterns())
+	})
+
+	t.Run("happy path: protected branch patterns ignore the tag rulesets", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  protected_tags:
+  - v*
+  rulesets:
+  - name: releases
+    enforcement: active
+    target: tag
+    conditions:
+      include:
+      - "refs/tags/release-*"
+    rules:
+    - ruletype: deletion
+  - name: signed
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, []string{"main"}, repos["repo1"].ProtectedBranchPatterns())
+	})
+
+	t.Run("not happy path: internal-only bypass app on a public repository", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  visibility: public
+  rulesets:
+  - name: protect
+    enforcement: active
+    bypassapps:
+    - appname: internal-bot
+      mode: always
+      justification: release automation
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{AppVisibilityScopes: map[string]string{"internal-bot": "internal"}})
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("happy path: visibility differing from the team default is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  defaultVisibility: private
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  visibility: public
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  visibility: public
+  visibility_override: true
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 2, len(repos))
+	})
+
+	t.Run("happy path: default branch protected by the repository and its team policy is a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+  - user2
+  policyRulesets:
+  - name: team-protection
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  rulesets:
+  - name: main
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  rulesets:
+  - name: release
+    enforcement: active
+    conditions:
+      include:
+      - "release/*"
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "repository repo1 ruleset main and team team1 policy ruleset team-protection")
+		assert.Equal(t, 2, len(repos))
+
+		// the team policy is expanded into the repositories rulesets
+		assert.Equal(t, 2, len(repos["repo2"].Spec.Rulesets))
+		assert.Equal(t, "team-protection", repos["repo2"].Spec.Rulesets[1].Name)
+		assert.Equal(t, 1, len(repos["repo2"].RulesetsForBranch("main")))
+	})
+
+	t.Run("happy path: default branch included twice is a warning", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  default_branch: develop
+  rulesets:
+  - name: default
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+      - main
+    rules:
+    - ruletype: deletion
+  - name: develop
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+      - develop
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "ruleset develop includes both ~DEFAULT_BRANCH and develop")
+	})
+
+	t.Run("not happy path: invalid ruleset names", func(t *testing.T) {
+		for _, name := range []string{strings.Repeat("a", RuleSetNameMaxLength+1), "main\tprotection"} {
+			repo := &Repository{}
+			repo.Name = "repo1"
+			repo.Spec.Rulesets = []RepositoryRuleSet{{Name: name, RuleSetDefinition: RuleSetDefinition{Enforcement: "active"}}}
+
+			err, _ := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "invalid ruleset name")
+		}
+	})
+
+	t.Run("happy path: auto merge and rebase merge without delete branch on merge emits warning", func(t *testing.T) {
+		enabled := true
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.AllowAutoMerge = true
+		repo.Spec.AllowRebaseMerge = &enabled
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		found := false
+		for _, w := range warns {
+			if strings.Contains(w.Error(), "allows auto merge and rebase merge without delete_branch_on_merge") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+
+		repo.Spec.DeleteBranchOnMerge = true
+		err, warns = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		for _, w := range warns {
+			assert.NotContains(t, w.Error(), "delete_branch_on_merge")
+		}
+	})
+
+	t.Run("happy path: auto merge with the default rebase merge without delete branch on merge emits warning", func(t *testing.T) {
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.AllowAutoMerge = true
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		promoted, _ := PromoteWarnings(warns, []string{"branch-pile-up"})
+		assert.Equal(t, 1, len(promoted))
+
+		disabled := false
+		repo.Spec.AllowRebaseMerge = &disabled
+		err, warns = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		promoted, _ = PromoteWarnings(warns, []string{"branch-pile-up"})
+		assert.Equal(t, 0, len(promoted))
+	})
+
+	t.Run("not happy path: name starting or ending with a special character", func(t *testing.T) {
+		for _, name := range []string{"_repo1", ".repo1", "repo1-", "repo1_"} {
+			repo := &Repository{}
+			repo.Name = name
+
+			err, _ := repo.Validate("teams/team1/"+name+".yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+			assert.NotNil(t, err, name)
+			if err != nil {
+				assert.Contains(t, err.Error(), "must not start or end with", name)
+			}
+		}
+	})
+
+	t.Run("happy path: external writer with a read-only role emits warning", func(t *testing.T) {
+		partner1 := &User{}
+		partner1.Name = "partner1"
+		partner1.Spec.Role = "partner"
+		externalUsers := map[string]*User{"partner1": partner1}
+
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.ExternalUserWriters = []string{"partner1"}
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, externalUsers, ValidationOptions{})
+		assert.Nil(t, err)
+		found := false
+		for _, w := range warns {
+			if strings.Contains(w.Error(), "externalUserWriter partner1 has the read-only role partner") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("happy path: discussions enabled with issues disabled emits warning", func(t *testing.T) {
+		enabled, disabled := true, false
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.HasDiscussions = &enabled
+		repo.Spec.HasIssues = &disabled
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		found := false
+		for _, w := range warns {
+			if strings.Contains(w.Error(), "repository repo1: has_discussions is enabled but has_issues is disabled") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+
+		repo.Spec.HasIssues = &enabled
+		err, warns = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		for _, w := range warns {
+			assert.NotContains(t, w.Error(), "has_discussions")
+		}
+	})
+
+	t.Run("not happy path: unknown custom property", func(t *testing.T) {
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.Properties = map[string]string{"tier": "1"}
+
+		opts := ValidationOptions{RepositoryPropertyKeys: map[string]bool{"team": true}}
+		err, _ := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, opts)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "unknown custom property tier")
+
+		opts.RepositoryPropertyKeys["tier"] = true
+		err, _ = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, opts)
+		assert.Nil(t, err)
+	})
+
+	t.Run("happy path: protected tags", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  protected_tags:
+  - v*
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(repo.Spec.Rulesets))
+		assert.Equal(t, ProtectedTagsRulesetName, repo.Spec.Rulesets[0].Name)
+		assert.Equal(t, "tag", repo.Spec.Rulesets[0].Target)
+		assert.Equal(t, []string{"refs/tags/v*"}, repo.Spec.Rulesets[0].Conditions.Include)
+		assert.Equal(t, 2, len(repo.Spec.Rulesets[0].Rules))
+		// a tag ruleset doesn't protect branches
+		assert.Equal(t, 0, len(repo.RulesetsForBranch("main")))
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(warns))
+	})
+
+	t.Run("not happy path: invalid protected tags", func(t *testing.T) {
+		fs := memfs.New()
+		for name, pattern := range map[string]string{"repo1": `""`, "repo2": `"v[0-9"`} {
+			err := utils.WriteFile(fs, "teams/team1/"+name+".yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: `+name+`
+spec:
+  protected_tags:
+  - `+pattern+`
+`), 0644)
+			assert.Nil(t, err)
+
+			_, err = NewRepository(fs, "teams/team1/"+name+".yaml")
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "invalid protected_tags")
+		}
+	})
+
+	t.Run("not happy path: branch only rule on a tag ruleset", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  rulesets:
+  - name: tags
+    target: tag
+    enforcement: active
+    conditions:
+      include:
+      - "refs/tags/v*"
+    rules:
+    - ruletype: pull_request
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		err, _ = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "cannot be used on a tag target")
+	})
+
+	t.Run("happy path: rulesets per branch", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  rulesets:
+  - name: main
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: pull_request
+      parameters:
+        requiredApprovingReviewCount: 1
+  - name: release
+    enforcement: active
+    conditions:
+      include:
+      - "release/*"
+      exclude:
+      - "release/experimental"
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+
+		repo := repos["repo1"]
+		assert.Equal(t, 1, len(repo.RulesetsForBranch("main")))
+		assert.Equal(t, "main", repo.RulesetsForBranch("main")[0].Name)
+		assert.Equal(t, 1, len(repo.RulesetsForBranch("release/1.0")))
+		assert.Equal(t, "release", repo.RulesetsForBranch("release/1.0")[0].Name)
+		assert.Equal(t, 0, len(repo.RulesetsForBranch("release/experimental")))
+		assert.Equal(t, 0, len(repo.RulesetsForBranch("feature/foo")))
+	})
+
+	t.Run("happy path: deprecated fields", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  public: true
+  rulesets:
+  - name: main
+    enforcement: active
+    bypassapps:
+    - appname: goliac-project-app
+      mode: always
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+
+		deprecations := fieldDeprecations{
+			repository: map[string]string{"public": "visibility"},
+			ruleset:    map[string]string{"bypassapps": "bypassActors"},
+		}
+		repo, err := readRepository(fs, "teams/team1/repo1.yaml", nil, false, deprecations)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"spec.public": "visibility", "spec.rulesets.bypassapps": "bypassActors"}, repo.DeprecatedFields)
+
+		err, warns := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Contains(t, warns[0].Error(), "field spec.public is deprecated, use visibility instead")
+		assert.Contains(t, warns[1].Error(), "field spec.rulesets.bypassapps is deprecated, use bypassActors instead")
+	})
+
+	t.Run("happy path: visibility", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  visibility: public
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  public: true
+`), 0644)
+		assert.Nil(t, err)
+
+		repo1, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		assert.True(t, repo1.Spec.IsPublic)
+		assert.Equal(t, 0, len(repo1.DeprecatedFields))
+
+		// public still works, with a deprecation warning
+		repo2, err := NewRepository(fs, "teams/team1/repo2.yaml")
+		assert.Nil(t, err)
+		assert.True(t, repo2.Spec.IsPublic)
+		err, warns := repo2.Validate("teams/team1/repo2.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "field spec.public is deprecated, use visibility instead")
+	})
+
+	t.Run("not happy path: invalid or conflicting visibility", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  visibility: internal
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  public: true
+  visibility: private
+`), 0644)
+		assert.Nil(t, err)
+
+		_, err = NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "invalid visibility: internal")
+
+		_, err = NewRepository(fs, "teams/team1/repo2.yaml")
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "public: true conflicts with visibility: private")
+	})
+
+	t.Run("happy path: ruleset contradictions", func(t *testing.T) {
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.Rulesets = []RepositoryRuleSet{
+			{
+				Name: "main",
+				RuleSetDefinition: RuleSetDefinition{
+					Enforcement: "active",
+					Conditions:  RuleSetConditions{Include: []string{"~DEFAULT_BRANCH"}},
+					Rules: []RuleSetRule{
+						{Ruletype: "pull_request", Parameters: RuleSetParameters{RequiredApprovingReviewCount: 1}},
+						{Ruletype: "deletion"},
+					},
+				},
+			},
+		}
+
+		orgRuleset := &RuleSet{}
+		orgRuleset.Name = "default"
+		orgRuleset.Spec.Conditions.Include = []string{"~ALL"}
+		orgRuleset.Spec.Rules = []RuleSetRule{
+			{Ruletype: "pull_request", Parameters: RuleSetParameters{RequiredApprovingReviewCount: 2}},
+			{Ruletype: "deletion"},
+		}
+		warns := repo.DetectRulesetContradictions([]*RuleSet{orgRuleset})
+		assert.Equal(t, 1, len(warns))
+
+		// not the same branches
+		orgRuleset.Spec.Conditions.Include = []string{"release/*"}
+		warns = repo.DetectRulesetContradictions([]*RuleSet{orgRuleset})
+		assert.Equal(t, 0, len(warns))
+	})
+
+	t.Run("happy path: external writers with an expiry", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  externalUserWriters:
+  - outside1
+  - name: outside2
+    expiry: 2999-12-31
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		externalUsers := map[string]*User{}
+		for _, name := range []string{"outside1", "outside2"} {
+			user := &User{}
+			user.Name = name
+			externalUsers[name] = user
+		}
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, externalUsers, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		repo := repos["repo1"]
+		assert.Equal(t, []string{"outside1", "outside2"}, repo.Spec.ExternalUserWriters)
+		assert.False(t, repo.ExternalUserWriterExpired("outside1", time.Now()))
+		assert.False(t, repo.ExternalUserWriterExpired("outside2", time.Date(2999, 12, 31, 23, 0, 0, 0, time.UTC)))
+		assert.True(t, repo.ExternalUserWriterExpired("outside2", time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+		// an expired grant is only a warning: the reconciler revokes it
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  externalUserWriters:
+  - name: outside2
+    expiry: 2001-01-01
+`), 0644)
+		assert.Nil(t, err)
+		repos, errs, warns = ReadRepositories(fs, "archived", "teams", teams, externalUsers, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.True(t, repos["repo1"].ExternalUserWriterExpired("outside2", time.Now()))
+		found := false
+		for _, w := range warns {
+			var coded *CodedWarning
+			if errors.As(w, &coded) && coded.Code == "expired-grant" {
+				found = true
+			}
+		}
+		assert.True(t, found)
+
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  externalUserWriters:
+  - name: outside2
+    expiry: tomorrow
+`), 0644)
+		assert.Nil(t, err)
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, externalUsers, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("not happy path: team granted multiple permission levels", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team2/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team2
+spec:
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+  - team2
+  triageTeams:
+  - team2
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "team2")
+		assert.Contains(t, errs[0].Error(), "triageTeams")
+		assert.Contains(t, errs[0].Error(), "writers")
+	})
+
+	t.Run("happy path: protection preset expanded into a ruleset", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  protection_preset: standard
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  protection_preset: paranoid
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 1, len(repos))
+		assert.Equal(t, 1, len(repos["repo1"].Spec.Rulesets))
+		assert.Equal(t, "protection-standard", repos["repo1"].Spec.Rulesets[0].Name)
+
+		// the template is not shared between repositories
+		repos["repo1"].Spec.Rulesets[0].Rules[0].Parameters.RequiredApprovingReviewCount = 3
+		assert.Equal(t, 1, ProtectionPresets["standard"].Rules[0].Parameters.RequiredApprovingReviewCount)
+	})
+
+	t.Run("not happy path: granted repository whose owner and maintain teams have no member", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/empty/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: empty
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/empty/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+  - team1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "no one can administer it")
+		assert.Equal(t, 0, len(repos))
+
+		// a maintain team with members can administer it
+		err = utils.WriteFile(fs, "teams/empty/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  maintainTeams:
+  - team1
+`), 0644)
+		assert.Nil(t, err)
+		repos, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(repos))
+	})
+
+	t.Run("not happy path: external users must never be admin", func(t *testing.T) {
+		externalUsers := map[string]*User{"outside1": {}}
+		owner := "team1"
+
+		tests := []struct {
+			name    string
+			owners  []string
+			members []string
+			wantErr bool
+		}{
+			{"external owner of the owning team", []string{"outside1"}, nil, true},
+			{"external member of the owning team", []string{"user1"}, []string{"outside1"}, true},
+			{"internal owning team", []string{"user1"}, []string{"user2"}, false},
+		}
+		for _, tt := range tests {
+			team1 := &Team{}
+			team1.Name = "team1"
+			team1.Spec.Owners = tt.owners
+			team1.Spec.Members = tt.members
+
+			repo := &Repository{}
+			repo.ApiVersion = "v1"
+			repo.Kind = "Repository"
+			repo.Name = "repo1"
+			repo.Owner = &owner
+
+			err, _ := repo.Validate("teams/team1/repo1.yaml", map[string]*Team{"team1": team1}, externalUsers, ValidationOptions{})
+			if tt.wantErr {
+				assert.NotNil(t, err, tt.name)
+				if err != nil {
+					assert.Contains(t, err.Error(), "external user outside1 would be granted admin access", tt.name)
+				}
+			} else {
+				assert.Nil(t, err, tt.name)
+			}
+		}
+	})
+
+	t.Run("not happy path: repository name not matching the naming policy", func(t *testing.T) {
+		repo := &Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "MyRepo"
+
+		err, _ := repo.Validate("teams/team1/MyRepo.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{RepositoryNamePattern: regexp.MustCompile(`^[a-z]+-[a-z0-9-]+$`)})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "doesn't match the repositories naming policy")
+
+		repo.Name = "team-repo1"
+		err, _ = repo.Validate("teams/team1/team-repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{RepositoryNamePattern: regexp.MustCompile(`^[a-z]+-[a-z0-9-]+$`)})
+		assert.Nil(t, err)
+
+		// no policy
+		repo.Name = "MyRepo"
+		err, _ = repo.Validate("teams/team1/MyRepo.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.Nil(t, err)
+	})
+
+	t.Run("not happy path: reserved repository name", func(t *testing.T) {
+		repo := &Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "Settings"
+
+		err, _ := repo.Validate("teams/team1/Settings.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "is a reserved name")
+
+		// the reserved names can be configured
+		err, _ = repo.Validate("teams/team1/Settings.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{RepositoryReservedNames: []string{}})
+		assert.Nil(t, err)
+
+		repo.Name = "repo1"
+		err, _ = repo.Validate("teams/team1/repo1.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{RepositoryReservedNames: []string{"REPO1"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("not happy path: every default reserved name is rejected as reserved", func(t *testing.T) {
+		for _, name := range RepositoryDefaultReservedNames {
+			repo := &Repository{}
+			repo.ApiVersion = "v1"
+			repo.Kind = "Repository"
+			repo.Name = name
+
+			err, _ := repo.Validate("teams/team1/"+name+".yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+			assert.NotNil(t, err, name)
+			if err != nil {
+				assert.Contains(t, err.Error(), "is a reserved name", name)
+			}
+		}
+	})
+
+	t.Run("not happy path: repositories read against a naming policy", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		opts := ValidationOptions{RepositoryNamePattern: regexp.MustCompile(`^[a-z]+-[a-z0-9-]+$`)}
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, opts)
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "doesn't match the repositories naming policy")
+		assert.Equal(t, 0, len(repos))
+	})
+
+	t.Run("happy path: custom filename to name mapping", func(t *testing.T) {
+		repo := &Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "repo1"
+
+		err, _ := repo.Validate("teams/team1/repo1/index.yaml", map[string]*Team{}, map[string]*User{}, ValidationOptions{})
+		assert.NotNil(t, err)
+
+		opts := ValidationOptions{RepositoryNameFromFile: func(path string) string {
+			return filepath.Base(filepath.Dir(path))
+		}}
+		err, _ = repo.Validate("teams/team1/repo1/index.yaml", map[string]*Team{}, map[string]*User{}, opts)
+		assert.Nil(t, err)
+	})
+
+	t.Run("happy path: team default topics are merged", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+  - user2
+  defaultTopics:
+  - team1
+  - backend
+`), 0644)
+		assert.Nil(t, err)
+
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  topics:
+  - backend
+  - golang
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, []string{"team1", "backend", "golang"}, repos["repo1"].Spec.Topics)
+	})
+
+	t.Run("not happy path: repo drifted from its owning team directory", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, "teams/team1/repo1.yaml", repos["repo1"].ExpectedPath("teams/team1"))
+
+		// the repository declares another owning team
+		err = utils.WriteFile(fs, "teams/team2/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team2
+spec:
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  owner: team2
+`), 0644)
+		assert.Nil(t, err)
+
+		teams, errs, _ = ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+
+		repos, errs, warns = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "declared owning team team2 directory is teams/team2")
+		assert.Equal(t, 1, len(repos))
+	})
+	t.Run("not happy path: repository under an undefined team", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/oldteam/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(repos))
+	})
+	t.Run("not happy path: team directories nested too deeply", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/subteam/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: subteam
+spec:
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{RepositoryMaxTeamDepth: 1})
+		assert.Equal(t, 1, len(errs))
+	})
+	t.Run("happy path: .yml repository is accepted with a warning", func(t *testing.T) {
+		// create a new user
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(repos))
+		assert.Equal(t, "teams/team1/repo1.yml", repos["repo1"].Filename)
+		assert.Equal(t, "teams/team2/repo1.yml", repos["repo1"].ExpectedPath("teams/team2"))
+		assert.Equal(t, "active definition teams/team1/repo1.yml", repos["repo1"].describeDefinition())
+		assert.Equal(t, "teams/team1/repo1.yml", validationFile(warns[0]))
+	})
+	t.Run("not happy path: repository in a team that cannot own repositories", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  canOwnRepos: false
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "cannot own repositories")
+		assert.Equal(t, 0, len(repos))
+	})
+	t.Run("not happy path: primary_language too long", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  primary_language: `+strings.Repeat("a", 51)+`
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "invalid primary_language")
+
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  primary_language: go
+`), 0644)
+		assert.Nil(t, err)
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, "go", repos["repo1"].Spec.PrimaryLanguage)
+	})
+	t.Run("happy path: team.yml is not read as a repository", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(repos))
+	})
+	t.Run("not happy path: the errors are attached to their file", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team2/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team2
+spec:
+  owners:
+  - user1
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team2/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "defined in 2 places")
+		assert.Contains(t, []string{"teams/team1/repo1.yaml", "teams/team2/repo1.yaml"}, validationFile(errs[0]))
+	})
+}
+
+func TestNewRepository(t *testing.T) {
+	t.Run("not happy path: not a repository file", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/ruleset1.yaml")
+		assert.Nil(t, repo)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "is not a Repository")
+	})
+
+	t.Run("not happy path: invalid apiVersion", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v2
+kind: Repository
+name: repo1
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, repo)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "invalid apiVersion: v2")
+	})
+}
+
+func TestValidateOrgLimits(t *testing.T) {
+	t.Run("happy path: no limit", func(t *testing.T) {
+		repos := map[string]*Repository{"repo1": {}, "repo2": {}}
+		assert.Equal(t, 0, len(ValidateOrgLimits(repos, 0)))
+	})
+
+	t.Run("not happy path: too many private repositories", func(t *testing.T) {
+		public := &Repository{}
+		public.Spec.IsPublic = true
+		repos := map[string]*Repository{
+			"repo1":    {},
+			"repo2":    {},
+			"archived": {Archived: true},
+			"public":   public,
+		}
+		assert.Equal(t, 0, len(ValidateOrgLimits(repos, 2)))
+		assert.Equal(t, 1, len(ValidateOrgLimits(repos, 1)))
+	})
+}
+
+func TestRepositoryClone(t *testing.T) {
+	t.Run("happy path: mutating the clone doesn't change the original", func(t *testing.T) {
+		owner := "team1"
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Owner = &owner
+		repo.Spec.Writers = []string{"team2"}
+		repo.Spec.Rulesets = []RepositoryRuleSet{{Name: "default"}}
+		repo.Spec.Rulesets[0].Conditions.Include = []string{"~DEFAULT_BRANCH"}
+		repo.Spec.Rulesets[0].Rules = []RuleSetRule{{Ruletype: "required_status_checks", Parameters: RuleSetParameters{RequiredStatusChecks: []string{"ci"}}}}
+
+		clone := repo.Clone()
+		clone.Spec.Writers[0] = "team3"
+		clone.Spec.Rulesets[0].Name = "other"
+		clone.Spec.Rulesets[0].Conditions.Include[0] = "main"
+		clone.Spec.Rulesets[0].Rules[0].Parameters.RequiredStatusChecks[0] = "other"
+		*clone.Owner = "team3"
+
+		assert.Equal(t, "team2", repo.Spec.Writers[0])
+		assert.Equal(t, "default", repo.Spec.Rulesets[0].Name)
+		assert.Equal(t, "~DEFAULT_BRANCH", repo.Spec.Rulesets[0].Conditions.Include[0])
+		assert.Equal(t, "ci", repo.Spec.Rulesets[0].Rules[0].Parameters.RequiredStatusChecks[0])
+		assert.Equal(t, "team1", *repo.Owner)
+	})
+}
+
+func TestNewRepositoryWithVariables(t *testing.T) {
+	t.Run("happy path: variables are substituted in the spec", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  primary_language: ${LANGUAGE}
+  writers:
+  - ${TEAM}
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepositoryWithVariables(fs, "repo1.yaml", map[string]string{"TEAM": "team1", "LANGUAGE": "go"}, true)
+		assert.Nil(t, err)
+		assert.Equal(t, "go", repo.Spec.PrimaryLanguage)
+		assert.Equal(t, []string{"team1"}, repo.Spec.Writers)
+	})
+
+	t.Run("not happy path: unresolved variable in strict mode", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+  - ${TEAM}
+`), 0644)
+		assert.Nil(t, err)
+
+		_, err = NewRepositoryWithVariables(fs, "repo1.yaml", map[string]string{}, true)
+		assert.NotNil(t, err)
+
+		repo, err := NewRepositoryWithVariables(fs, "repo1.yaml", map[string]string{}, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"${TEAM}"}, repo.Spec.Writers)
+	})
+}
+
+func TestReadRepositoriesVariables(t *testing.T) {
+	t.Run("happy path: the configured variables are substituted", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  primary_language: ${LANGUAGE}
+  writers:
+  - ${TEAM}
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "archived/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  primary_language: ${LANGUAGE}
+`), 0644)
+		assert.Nil(t, err)
+
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		opts := ValidationOptions{Variables: map[string]string{"LANGUAGE": "go"}, StrictVariables: true}
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, opts)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, "go", repos["repo1"].Spec.PrimaryLanguage)
+		assert.Equal(t, []string{"team1"}, repos["repo1"].Spec.Writers)
+		assert.Equal(t, "go", repos["repo2"].Spec.PrimaryLanguage)
+	})
+
+	t.Run("not happy path: unresolved variable with strict variables", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  primary_language: ${LANGUAGE}
+`), 0644)
+		assert.Nil(t, err)
+
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+
+		_, errs, _ = ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{StrictVariables: true})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "LANGUAGE")
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, "${LANGUAGE}", repos["repo1"].Spec.PrimaryLanguage)
+	})
+}
+
+func TestRepositoryChecksum(t *testing.T) {
+	t.Run("happy path: reordering lists doesn't change the checksum", func(t *testing.T) {
+		r1 := &Repository{}
+		r1.Name = "repo1"
+		r1.Spec.Writers = []string{"team1", "team2"}
+		r1.DirectoryPath = "teams/team1"
+
+		r2 := r1.Clone()
+		r2.Spec.Writers = []string{"team2", "team1"}
+		r2.DirectoryPath = "teams/team2"
+
+		assert.Equal(t, r1.Checksum(), r2.Checksum())
+		assert.Equal(t, []string{"team2", "team1"}, r2.Spec.Writers)
+	})
+	t.Run("happy path: spec changes change the checksum", func(t *testing.T) {
+		r1 := &Repository{}
+		r1.Name = "repo1"
+		r1.Spec.Writers = []string{"team1"}
+
+		r2 := r1.Clone()
+		r2.Spec.Readers = []string{"team2"}
+
+		assert.NotEqual(t, r1.Checksum(), r2.Checksum())
+	})
+	t.Run("happy path: owner and expiry changes change the checksum", func(t *testing.T) {
+		team1 := "team1"
+		team2 := "team2"
+		r1 := &Repository{}
+		r1.Name = "repo1"
+		r1.Owner = &team1
+		r1.Spec.ExternalUserWriters = []string{"external1"}
+		r1.ExternalUserWritersExpiry = map[string]string{"external1": "2030-01-01"}
+
+		r2 := r1.Clone()
+		r2.Owner = &team2
+		assert.NotEqual(t, r1.Checksum(), r2.Checksum())
+
+		r3 := r1.Clone()
+		r3.ExternalUserWritersExpiry["external1"] = "2031-01-01"
+		assert.NotEqual(t, r1.Checksum(), r3.Checksum())
+	})
+}
+
+func TestCrossValidateRepositories(t *testing.T) {
+	t.Run("not happy path: archived repository with external writers", func(t *testing.T) {
+		repo1 := &Repository{}
+		repo1.Name = "repo1"
+		repo1.Archived = true
+		repo1.ArchivedReason = "deprecated"
+		repo1.Spec.ExternalUserWriters = []string{"partner1"}
+		repo2 := &Repository{}
+		repo2.Name = "repo2"
+		repo2.Archived = true
+		repo2.ArchivedReason = "deprecated"
+		repo2.Spec.ExternalUserReaders = []string{"partner1"}
+
+		errs, warns := crossValidateRepositories(map[string]*Repository{"repo1": repo1, "repo2": repo2}, map[string]*Team{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 1, len(warns))
+	})
+
+	t.Run("happy path: archived repository still granted to teams", func(t *testing.T) {
+		repo1 := &Repository{}
+		repo1.Name = "repo1"
+		repo1.Archived = true
+		repo1.Spec.Writers = []string{"team1"}
+		repo2 := &Repository{}
+		repo2.Name = "repo2"
+		repo2.Archived = true
+		repo3 := &Repository{}
+		repo3.Name = "repo3"
+		repo3.Archived = true
+		repo3.Spec.TriageTeams = []string{"team1"}
+		repo4 := &Repository{}
+		repo4.Name = "repo4"
+		repo4.Archived = true
+		repo4.Spec.MaintainTeams = []string{"team1"}
+
+		errs, warns := crossValidateRepositories(map[string]*Repository{"repo1": repo1, "repo2": repo2, "repo3": repo3, "repo4": repo4}, map[string]*Team{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 3, len(warns))
+		var coded *CodedWarning
+		assert.True(t, errors.As(warns[0], &coded))
+		assert.Equal(t, "archived-grants", coded.Code)
+		assert.Contains(t, warns[0].Error(), "archived repository repo1 is still granted to teams")
+		assert.Contains(t, warns[1].Error(), "archived repository repo3 is still granted to teams")
+		assert.Contains(t, warns[2].Error(), "archived repository repo4 is still granted to teams")
+	})
+
+	t.Run("happy path: repository only accessible by external users", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/empty/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: empty
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/empty/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  externalUserWriters:
+  - outside1
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  externalUserReaders:
+  - outside1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		outside1 := &User{}
+		outside1.Name = "outside1"
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{"outside1": outside1}, ValidationOptions{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 2, len(repos))
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "repository repo1 has no internal team with members")
+	})
+}
+
+func TestCrossValidateRenameChains(t *testing.T) {
+	t.Run("not happy path: rename chain", func(t *testing.T) {
+		repos := map[string]*Repository{}
+		for _, name := range []string{"repoA", "repoB"} {
+			repo := &Repository{}
+			repo.Name = name
+			repos[name] = repo
+		}
+		repos["repoA"].RenameTo = "repoB"
+		repos["repoB"].RenameTo = "repoC"
+
+		errs, _ := crossValidateRepositories(repos, map[string]*Team{})
+		assert.Equal(t, 1, len(errs))
+
+		repos["repoB"].RenameTo = ""
+		errs, _ = crossValidateRepositories(repos, map[string]*Team{})
+		assert.Equal(t, 0, len(errs))
+	})
+}
+
+func TestValidateRename(t *testing.T) {
+	repos := map[string]*Repository{}
+	for _, name := range []string{"repo1", "repo2", "repo3"} {
+		repo := &Repository{}
+		repo.Name = name
+		repos[name] = repo
+	}
+	repos["repo3"].RenameTo = "repo4"
+
+	t.Run("happy path", func(t *testing.T) {
+		assert.Equal(t, 0, len(ValidateRename(repos, "repo1", "repo5", ValidationOptions{})))
+	})
+	t.Run("not happy path: collision with an existing repository", func(t *testing.T) {
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "Repo2", ValidationOptions{})))
+	})
+	t.Run("not happy path: collision with a pending rename", func(t *testing.T) {
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "repo4", ValidationOptions{})))
+	})
+	t.Run("not happy path: invalid name", func(t *testing.T) {
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "repo5.git", ValidationOptions{})))
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "repo5-", ValidationOptions{})))
+	})
+	t.Run("not happy path: reserved name", func(t *testing.T) {
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "Settings", ValidationOptions{})))
+		assert.Equal(t, 0, len(ValidateRename(repos, "repo1", "settings", ValidationOptions{RepositoryReservedNames: []string{}})))
+		assert.Equal(t, 1, len(ValidateRename(repos, "repo1", "repo5", ValidationOptions{RepositoryReservedNames: []string{"repo5"}})))
+	})
+}
+
+func TestSortedRepositoryNames(t *testing.T) {
+	t.Run("happy path: names are sorted", func(t *testing.T) {
+		repos := map[string]*Repository{}
+		for _, name := range []string{"repo3", "Repo2", "repo1", "repo10"} {
+			repos[name] = &Repository{}
+		}
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, []string{"Repo2", "repo1", "repo10", "repo3"}, SortedRepositoryNames(repos))
+		}
+	})
+	t.Run("happy path: empty", func(t *testing.T) {
+		assert.Equal(t, []string{}, SortedRepositoryNames(map[string]*Repository{}))
+	})
+}
+
+func TestRequiresIssueTemplate(t *testing.T) {
+	repo := &Repository{}
+	repo.Name = "repo1"
+	repo.DirectoryPath = "teams/team1"
+
+	t.Run("happy path: no repository contents", func(t *testing.T) {
+		fs := memfs.New()
+		assert.Equal(t, 0, len(repo.RequiresIssueTemplate(fs)))
+	})
+	t.Run("not happy path: contents without issue template", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1/README.md", []byte("repo1"), 0644)
+		assert.Nil(t, err)
+		warns := repo.RequiresIssueTemplate(fs)
+		assert.Equal(t, 1, len(warns))
+		assert.Contains(t, warns[0].Error(), "(check repository filename teams/team1/repo1.yaml)")
+	})
+	t.Run("happy path: contents with issue template", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/repo1/.github/ISSUE_TEMPLATE/bug.md", []byte("bug"), 0644)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(repo.RequiresIssueTemplate(fs)))
+	})
+}
+
+func TestValidateFeatureDependencies(t *testing.T) {
+	t.Run("happy path: nothing enabled", func(t *testing.T) {
+		repo := &Repository{}
+		errs, warns := repo.ValidateFeatureDependencies()
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+	})
+	t.Run("not happy path: merge queue without auto merge", func(t *testing.T) {
+		repo := &Repository{}
+		repo.Spec.Rulesets = []RepositoryRuleSet{
+			{RuleSetDefinition: RuleSetDefinition{Rules: []RuleSetRule{{Ruletype: "merge_queue"}}}},
+		}
+		errs, warns := repo.ValidateFeatureDependencies()
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(warns))
+
+		repo.Spec.AllowAutoMerge = true
+		errs, _ = repo.ValidateFeatureDependencies()
+		assert.Equal(t, 0, len(errs))
+	})
+	t.Run("happy path: discussions without issues is only a warning", func(t *testing.T) {
+		enabled := true
+		disabled := false
+		repo := &Repository{}
+		repo.Spec.HasDiscussions = &enabled
+		repo.Spec.HasIssues = &disabled
+		errs, warns := repo.ValidateFeatureDependencies()
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		_, ok := warns[0].(*CodedWarning)
+		assert.True(t, ok)
+	})
+}
+
+func TestReposPendingDeletion(t *testing.T) {
+	t.Run("happy path: only repositories marked for deletion", func(t *testing.T) {
+		repos := map[string]*Repository{}
+		for _, name := range []string{"repo3", "repo1", "repo2"} {
+			repo := &Repository{}
+			repo.Name = name
+			repos[name] = repo
+		}
+		repos["repo3"].MarkForDeletion()
+		repos["repo1"].MarkForDeletion()
+
+		pending := ReposPendingDeletion(repos)
+		assert.Equal(t, 2, len(pending))
+		assert.Equal(t, "repo1", pending[0].Name)
+		assert.Equal(t, "repo3", pending[1].Name)
+	})
+}
+
+func TestReconciliationOrder(t *testing.T) {
+	t.Run("happy path: renames, then creations, then archives", func(t *testing.T) {
+		repos := map[string]*Repository{
+			"b-archived": {Entity: Entity{Name: "b-archived"}, Archived: true},
+			"a-archived": {Entity: Entity{Name: "a-archived"}, Archived: true},
+			"regular":    {Entity: Entity{Name: "regular"}},
+			"renamed":    {Entity: Entity{Name: "renamed"}, RenameTo: "newname"},
+		}
+
+		ordered := ReconciliationOrder(repos)
+		names := []string{}
+		for _, r := range ordered {
+			names = append(names, r.Name)
+		}
+		assert.Equal(t, []string{"renamed", "regular", "a-archived", "b-archived"}, names)
+	})
+
+	t.Run("happy path: deletions come last", func(t *testing.T) {
+		repos := map[string]*Repository{
+			"a-deleted":  {Entity: Entity{Name: "a-deleted"}, Deleted: true},
+			"b-archived": {Entity: Entity{Name: "b-archived"}, Archived: true},
+			"regular":    {Entity: Entity{Name: "regular"}},
+		}
+
+		ordered := ReconciliationOrder(repos)
+		names := []string{}
+		for _, r := range ordered {
+			names = append(names, r.Name)
+		}
+		assert.Equal(t, []string{"regular", "b-archived", "a-deleted"}, names)
+	})
+}
+
+func TestDetectPermissionConflicts(t *testing.T) {
+	t.Run("happy path: no conflict", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.Writers = []string{"parent", "child"}
+
+		warnings := repo.DetectPermissionConflicts(map[string]string{"child": "parent"})
+		assert.Equal(t, 0, len(warnings))
+	})
+
+	t.Run("not happy path: parent and child with different access", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.Readers = []string{"child"}
+		repo.Spec.Writers = []string{"parent"}
+
+		warnings := repo.DetectPermissionConflicts(map[string]string{"child": "parent"})
+		assert.Equal(t, 1, len(warnings))
+	})
+
+	t.Run("not happy path: triage and maintain teams are taken into account", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.TriageTeams = []string{"child"}
+		repo.Spec.MaintainTeams = []string{"parent"}
+
+		warnings := repo.DetectPermissionConflicts(map[string]string{"child": "parent"})
+		assert.Equal(t, 1, len(warnings))
+		assert.Contains(t, warnings[0].Error(), "team child has triage access but its parent team parent has maintain access")
+	})
+}
+
+func TestValidateTeamsNonEmpty(t *testing.T) {
+	teams := map[string]*Team{
+		"team1": {Entity: Entity{Name: "team1"}},
+		"empty": {Entity: Entity{Name: "empty"}},
+	}
+	teams["team1"].Spec.Owners = []string{"user1"}
+
+	t.Run("happy path: teams with members", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.Writers = []string{"team1"}
+
+		warnings := ValidateTeamsNonEmpty(map[string]*Repository{"repo1": repo}, teams)
+		assert.Equal(t, 0, len(warnings))
+	})
+
+	t.Run("not happy path: writer team without member", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.Writers = []string{"empty"}
+
+		warnings := ValidateTeamsNonEmpty(map[string]*Repository{"repo1": repo}, teams)
+		assert.Equal(t, 1, len(warnings))
+	})
+
+	t.Run("not happy path: triage and maintain teams without member", func(t *testing.T) {
+		repo := &Repository{Entity: Entity{Name: "repo1"}}
+		repo.Spec.TriageTeams = []string{"empty"}
+		repo.Spec.MaintainTeams = []string{"empty"}
+
+		warnings := ValidateTeamsNonEmpty(map[string]*Repository{"repo1": repo}, teams)
+		assert.Equal(t, 2, len(warnings))
+	})
+}
+
+func TestDiffRuleSets(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		unchanged := RepositoryRuleSet{Name: "unchanged"}
+		unchanged.Enforcement = "active"
+		changedBefore := RepositoryRuleSet{Name: "changed"}
+		changedBefore.Enforcement = "evaluate"
+		changedAfter := RepositoryRuleSet{Name: "changed"}
+		changedAfter.Enforcement = "active"
+
+		added, removed, changed := DiffRuleSets(
+			[]RepositoryRuleSet{unchanged, changedBefore, {Name: "removed"}},
+			[]RepositoryRuleSet{unchanged, changedAfter, {Name: "added"}},
+		)
+		assert.Equal(t, []string{"added"}, added)
+		assert.Equal(t, []string{"removed"}, removed)
+		assert.Equal(t, []string{"changed"}, changed)
+	})
+}
+
+func TestLintRepositoryFile(t *testing.T) {
+	t.Run("happy path: clean file", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+  - team1
+`), 0644)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(LintRepositoryFile(fs, "repo1.yaml")))
+	})
+
+	t.Run("not happy path: stylistic issues", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "repo1.yaml", []byte(`
+apiVersion: v1
+name: repo1
+kind: Repository
+spec:
+  writers: []
+  public: false
+`), 0644)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(LintRepositoryFile(fs, "repo1.yaml")))
+	})
+}
+
+func TestRepositoryInclude(t *testing.T) {
+	t.Run("happy path: fragments rulesets are merged", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "teams/team1/.fragments/protection.yaml", []byte(`
+rulesets:
+- name: protection
+  enforcement: active
+  conditions:
+    include:
+    - "~DEFAULT_BRANCH"
+  rules:
+  - ruletype: deletion
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+include:
+- .fragments/protection.yaml
+`), 0644)
+		assert.Nil(t, err)
+
+		repo, err := NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(repo.Spec.Rulesets))
+		assert.Equal(t, "protection", repo.Spec.Rulesets[0].Name)
+	})
+
+	t.Run("not happy path: duplicated ruleset name across fragments", func(t *testing.T) {
+		fs := memfs.New()
+		for _, fragment := range []string{"a", "b"} {
+			err := utils.WriteFile(fs, "teams/team1/.fragments/"+fragment+".yaml", []byte(`
+rulesets:
+- name: protection
+  enforcement: active
+  rules:
+  - ruletype: deletion
+`), 0644)
+			assert.Nil(t, err)
+		}
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+include:
+- .fragments/a.yaml
+- .fragments/b.yaml
+`), 0644)
+		assert.Nil(t, err)
+
+		_, err = NewRepository(fs, "teams/team1/repo1.yaml")
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "teams/team1/.fragments/a.yaml")
+		assert.Contains(t, err.Error(), "teams/team1/.fragments/b.yaml")
+	})
+}
+
+func TestReposMissingRuleset(t *testing.T) {
+	repos := map[string]*Repository{}
+	for _, name := range []string{"repo3", "repo1", "repo2", "other"} {
+		repo := &Repository{}
+		repo.Name = name
+		repos[name] = repo
+	}
+	repos["repo2"].Spec.Rulesets = []RepositoryRuleSet{{Name: "default"}}
+	matchRepo := func(repo *Repository) bool {
+		return strings.HasPrefix(repo.Name, "repo")
+	}
+
+	t.Run("happy path: matching repositories without the ruleset", func(t *testing.T) {
+		assert.Equal(t, []string{"repo1", "repo3"}, ReposMissingRuleset(repos, "default", matchRepo))
+	})
+	t.Run("happy path: all the matching repositories define the ruleset", func(t *testing.T) {
+		for _, name := range []string{"repo1", "repo3"} {
+			repos[name].Spec.Rulesets = []RepositoryRuleSet{{Name: "default"}}
+		}
+		assert.Equal(t, []string{}, ReposMissingRuleset(repos, "default", matchRepo))
+	})
+}
+
+func TestValidatePublicApproval(t *testing.T) {
+	repos := map[string]*Repository{}
+	for _, name := range []string{"public1", "public2", "private1", "archived1"} {
+		repo := &Repository{}
+		repo.Name = name
+		repo.Spec.IsPublic = name != "private1"
+		repos[name] = repo
+	}
+	repos["archived1"].Archived = true
+
+	t.Run("happy path: the organization is public by default", func(t *testing.T) {
+		errs := ValidatePublicApproval(repos, "public", map[string]bool{})
+		assert.Equal(t, 0, len(errs))
+	})
+	t.Run("happy path: all the public repositories are approved", func(t *testing.T) {
+		errs := ValidatePublicApproval(repos, "private", map[string]bool{"public1": true, "public2": true})
+		assert.Equal(t, 0, len(errs))
+	})
+	t.Run("not happy path: unapproved public repository", func(t *testing.T) {
+		errs := ValidatePublicApproval(repos, "private", map[string]bool{"public1": true})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "repository public2 is public")
 	})
 }
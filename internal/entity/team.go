@@ -3,6 +3,7 @@ package entity
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Alayacare/goliac/internal/config"
@@ -17,6 +18,11 @@ type Team struct {
 		ExternallyManaged bool     `yaml:"externallyManaged,omitempty"`
 		Owners            []string `yaml:"owners,omitempty"`
 		Members           []string `yaml:"members,omitempty"`
+		CanOwnRepos       *bool    `yaml:"canOwnRepos,omitempty"`       // nil: the team can own repositories
+		DefaultTopics     []string `yaml:"defaultTopics,omitempty"`     // topics added to all the team's repositories
+		DefaultVisibility string   `yaml:"defaultVisibility,omitempty"` // public, private: expected visibility of the team's repositories
+		// branch protection the team mandates on its repositories (policy)
+		PolicyRulesets []RepositoryRuleSet `yaml:"policyRulesets,omitempty"`
 	} `yaml:"spec"`
 	ParentTeam *string `yaml:"-"`
 }
@@ -36,6 +42,13 @@ func NewTeam(fs billy.Filesystem, filename string, parent *string) (*Team, error
 	if err != nil {
 		return nil, err
 	}
+	for i := range team.Spec.PolicyRulesets {
+		team.Spec.PolicyRulesets[i].Enforcement, err = NormalizeEnforcement(team.Spec.PolicyRulesets[i].Enforcement)
+		if err != nil {
+			return nil, fmt.Errorf("%v for policy ruleset %s in team filename %s", err, team.Spec.PolicyRulesets[i].Name, filename)
+		}
+		team.Spec.PolicyRulesets[i].normalize()
+	}
 
 	if parent != nil {
 		team.ParentTeam = parent
@@ -50,7 +63,7 @@ func NewTeam(fs billy.Filesystem, filename string, parent *string) (*Team, error
  * - a slice of errors that must stop the validation process
  * - a slice of warning that must not stop the validation process
  */
-func ReadTeamDirectory(fs billy.Filesystem, dirname string, users map[string]*User) (map[string]*Team, []error, []Warning) {
+func ReadTeamDirectory(fs billy.Filesystem, dirname string, users map[string]*User, opts ValidationOptions) (map[string]*Team, []error, []Warning) {
 	errors := []error{}
 	warning := []Warning{}
 	teams := make(map[string]*Team)
@@ -79,22 +92,23 @@ func ReadTeamDirectory(fs billy.Filesystem, dirname string, users map[string]*Us
 			continue
 		}
 
-		recursiveReadTeamDirectory(fs, filepath.Join(dirname, e.Name()), nil, users, teams, &errors, &warning)
+		recursiveReadTeamDirectory(fs, filepath.Join(dirname, e.Name()), nil, users, teams, &errors, &warning, opts)
 	}
 	return teams, errors, warning
 }
 
-func recursiveReadTeamDirectory(fs billy.Filesystem, dirname string, parentTeam *string, users map[string]*User, teams map[string]*Team, errors *[]error, warning *[]Warning) {
+func recursiveReadTeamDirectory(fs billy.Filesystem, dirname string, parentTeam *string, users map[string]*User, teams map[string]*Team, errors *[]error, warning *[]Warning, opts ValidationOptions) {
 
-	team, err := NewTeam(fs, filepath.Join(dirname, "team.yaml"), parentTeam)
+	filename := filepath.Join(dirname, "team.yaml")
+	team, err := NewTeam(fs, filename, parentTeam)
 	if err != nil {
-		*errors = append(*errors, err)
+		*errors = append(*errors, withFile(filename, err))
 		return
 	} else {
-		err, warns := team.Validate(dirname, users)
-		*warning = append(*warning, warns...)
+		err, warns := team.Validate(dirname, users, opts)
+		*warning = append(*warning, warningsWithFile(filename, warns)...)
 		if err != nil {
-			*errors = append(*errors, err)
+			*errors = append(*errors, withFile(filename, err))
 			return
 		} else {
 			teams[team.Name] = team
@@ -123,11 +137,11 @@ func recursiveReadTeamDirectory(fs billy.Filesystem, dirname string, parentTeam
 			continue
 		}
 
-		recursiveReadTeamDirectory(fs, filepath.Join(dirname, e.Name()), &parent, users, teams, errors, warning)
+		recursiveReadTeamDirectory(fs, filepath.Join(dirname, e.Name()), &parent, users, teams, errors, warning, opts)
 	}
 }
 
-func (t *Team) Validate(dirname string, users map[string]*User) (error, []Warning) {
+func (t *Team) Validate(dirname string, users map[string]*User, opts ValidationOptions) (error, []Warning) {
 	warnings := []Warning{}
 
 	if t.ApiVersion != "v1" {
@@ -176,15 +190,133 @@ func (t *Team) Validate(dirname string, users map[string]*User) (error, []Warnin
 		}
 	}
 
+	if t.Spec.DefaultVisibility != "" && t.Spec.DefaultVisibility != "public" && t.Spec.DefaultVisibility != "private" {
+		return fmt.Errorf("invalid defaultVisibility: %s must be 'public' or 'private' in team filename %s/team.yaml", t.Spec.DefaultVisibility, dirname), warnings
+	}
+
+	policynames := make(map[string]bool)
+	for _, ruleset := range t.Spec.PolicyRulesets {
+		if ruleset.Name == "" {
+			return fmt.Errorf("policy ruleset without name in team filename %s/team.yaml", dirname), warnings
+		}
+		if err := validateRuleSetName(ruleset.Name, filepath.Join(dirname, "team.yaml")); err != nil {
+			return err, warnings
+		}
+		if policynames[ruleset.Name] {
+			return fmt.Errorf("invalid policy ruleset: each policy ruleset must have a uniq name, found 2 times %s in team filename %s/team.yaml", ruleset.Name, dirname), warnings
+		}
+		policynames[ruleset.Name] = true
+		err, warns := ruleset.validateDefinition(ruleset.Name, filepath.Join(dirname, "team.yaml"), opts)
+		warnings = append(warnings, warns...)
+		if err != nil {
+			return err, warnings
+		}
+	}
+
 	// warnings
 
 	if len(t.Spec.Owners) < 2 && !t.Spec.ExternallyManaged {
-		warnings = append(warnings, fmt.Errorf("not enough owners for team filename %s/team.yaml", dirname))
+		warnings = append(warnings, NewCodedWarning("not-enough-owners", "not enough owners for team filename %s/team.yaml", dirname))
 	}
 
 	return nil, warnings
 }
 
+/*
+ * HasMembers returns true if the team has at least one owner or member.
+ * Externally managed teams are assumed to have members
+ */
+func (t *Team) HasMembers() bool {
+	return t.Spec.ExternallyManaged || len(t.Spec.Owners) > 0 || len(t.Spec.Members) > 0
+}
+
+/*
+ * CanOwnRepositories returns true if repositories can be defined in the team directory
+ */
+func (t *Team) CanOwnRepositories() bool {
+	return t.Spec.CanOwnRepos == nil || *t.Spec.CanOwnRepos
+}
+
+/*
+ * ValidateTeamDirectories checks that the teams directories (under teamDir)
+ * match the teams definitions: each team must have its own directory (nested
+ * under its parent team directory), and each directory must define a team
+ */
+func ValidateTeamDirectories(fs billy.Filesystem, teamDir string, teams map[string]*Team) []error {
+	errors := []error{}
+
+	expected := make(map[string]string) // directory -> team name
+	for teamname, team := range teams {
+		dirpath := teamname
+		visited := map[string]bool{teamname: true}
+		for parent := team.ParentTeam; parent != nil; {
+			if visited[*parent] {
+				break
+			}
+			visited[*parent] = true
+			dirpath = filepath.Join(*parent, dirpath)
+			if p, ok := teams[*parent]; ok {
+				parent = p.ParentTeam
+			} else {
+				parent = nil
+			}
+		}
+		expected[filepath.Join(teamDir, dirpath)] = teamname
+	}
+
+	found := make(map[string]bool)
+	var walk func(dirname string) error
+	walk = func(dirname string) error {
+		entries, err := fs.ReadDir(dirname)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.IsDir() || e.Name()[0] == '.' {
+				continue
+			}
+			subdir := filepath.Join(dirname, e.Name())
+			found[subdir] = true
+			if err := walk(subdir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	exist, err := utils.Exists(fs, teamDir)
+	if err != nil {
+		return append(errors, err)
+	}
+	if exist {
+		if err := walk(teamDir); err != nil {
+			return append(errors, err)
+		}
+	}
+
+	dirs := make([]string, 0, len(found))
+	for dir := range found {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if _, ok := expected[dir]; !ok {
+			errors = append(errors, fmt.Errorf("directory %s doesn't correspond to a defined team", dir))
+		}
+	}
+
+	dirs = make([]string, 0, len(expected))
+	for dir := range expected {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if !found[dir] {
+			errors = append(errors, fmt.Errorf("team %s has no directory (expected %s)", expected[dir], dir))
+		}
+	}
+	return errors
+}
+
 /**
  * AdjustTeamDirectory adjust team's defintion depending on user availability.
  * The goal is that if a user has been removed, we must update the team definition.
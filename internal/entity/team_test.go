@@ -57,7 +57,7 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
@@ -83,7 +83,7 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 1)
 		assert.NotNil(t, teams)
@@ -93,7 +93,7 @@ spec:
 		// create a new user
 		fs := memfs.New()
 
-		_, errs, warns := ReadTeamDirectory(fs, "teams", map[string]*User{})
+		_, errs, warns := ReadTeamDirectory(fs, "teams", map[string]*User{}, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -119,12 +119,44 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 1)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
 	})
 
+	t.Run("not happy path: invalid policy ruleset", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+  - user2
+  policyRulesets:
+  - name: team-protection
+    enforcement: active
+    conditions:
+      include:
+      - "~DEFAULT_BRANCH"
+    rules:
+    - ruletype: unknown
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "invalid rulettype: unknown")
+		assert.Equal(t, 0, len(teams))
+	})
+
 	t.Run("not happy path: missing specs", func(t *testing.T) {
 		// create a new user
 		fs := memfs.New()
@@ -142,7 +174,7 @@ name: team2
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 1)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
@@ -170,7 +202,7 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		_, errs, warns = ReadTeamDirectory(fs, "teams", users)
+		_, errs, warns = ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.NotEqual(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -226,7 +258,7 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		_, errs, warns = ReadTeamDirectory(fs, "teams", users)
+		_, errs, warns = ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.NotEqual(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 	})
@@ -262,7 +294,7 @@ spec:
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, users)
 
-		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users, ValidationOptions{})
 		assert.Equal(t, len(errs), 0)
 		assert.Equal(t, len(warns), 0)
 		assert.NotNil(t, teams)
@@ -273,6 +305,16 @@ spec:
 		subteam := teams["subteam"]
 		assert.NotNil(t, subteam)
 		assert.Equal(t, "team1", *subteam.ParentTeam)
+
+		assert.Equal(t, 0, len(ValidateTeamDirectories(fs, "teams", teams)))
+
+		// a directory without team, and a team without directory
+		fs.MkdirAll("teams/team1/oldteam", 0755)
+		orphan := &Team{}
+		orphan.Name = "team2"
+		teams["team2"] = orphan
+		errs = ValidateTeamDirectories(fs, "teams", teams)
+		assert.Equal(t, 2, len(errs))
 	})
 }
 
@@ -3,7 +3,6 @@ package entity
 import (
 	"fmt"
 	"path/filepath"
-	"strings"
 
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
@@ -14,6 +13,7 @@ type User struct {
 	Entity `yaml:",inline"`
 	Spec   struct {
 		GithubID string `yaml:"githubID"`
+		Role     string `yaml:"role,omitempty"` // only for external users (see ExternalUserRoles)
 	} `yaml:"spec"`
 }
 
@@ -43,24 +43,35 @@ func NewUser(fs billy.Filesystem, filename string) (*User, error) {
  * - a slice of warning that must not stop the validation process
  */
 func ReadUserDirectory(fs billy.Filesystem, dirname string) (map[string]*User, []error, []Warning) {
+	users, _, errors, warning := readUserDirectory(fs, dirname)
+	return users, errors, warning
+}
+
+/*
+ * readUserDirectory is ReadUserDirectory also returning the file each user
+ * is defined in (user name -> filename)
+ */
+func readUserDirectory(fs billy.Filesystem, dirname string) (map[string]*User, map[string]string, []error, []Warning) {
 	errors := []error{}
 	warning := []Warning{}
 	users := make(map[string]*User)
+	// user name -> filename
+	filenames := make(map[string]string)
 
 	exist, err := utils.Exists(fs, dirname)
 	if err != nil {
 		errors = append(errors, err)
-		return users, errors, warning
+		return users, filenames, errors, warning
 	}
 	if !exist {
-		return users, errors, warning
+		return users, filenames, errors, warning
 	}
 
 	// Parse all the users in the dirname directory
 	entries, err := fs.ReadDir(dirname)
 	if err != nil {
 		errors = append(errors, err)
-		return users, errors, warning
+		return users, filenames, errors, warning
 	}
 
 	for _, e := range entries {
@@ -71,22 +82,53 @@ func ReadUserDirectory(fs billy.Filesystem, dirname string) (map[string]*User, [
 		if e.Name()[0] == '.' {
 			continue
 		}
-		if !strings.HasSuffix(e.Name(), ".yaml") {
+		if !isYamlFile(e.Name()) {
 			continue
 		}
-		user, err := NewUser(fs, filepath.Join(dirname, e.Name()))
+		filename := filepath.Join(dirname, e.Name())
+		if filepath.Ext(e.Name()) == ".yml" {
+			warning = append(warning, ymlExtensionWarning(filename))
+		}
+		user, err := NewUser(fs, filename)
 		if err != nil {
-			errors = append(errors, err)
+			errors = append(errors, withFile(filename, err))
 		} else {
-			err = user.Validate(filepath.Join(dirname, e.Name()))
+			err = user.Validate(filename)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, withFile(filename, err))
+			} else if other, ok := filenames[user.Name]; ok {
+				errors = append(errors, withFile(filename, fmt.Errorf("User %s defined in 2 places (check %s and %s)", user.Name, filename, other)))
 			} else {
+				filenames[user.Name] = filename
 				users[user.Name] = user
 			}
 		}
 
 	}
+	return users, filenames, errors, warning
+}
+
+/*
+ * ExternalUserRoles are the roles an external user can have,
+ * with the access they are expected to be granted
+ */
+var ExternalUserRoles = map[string]string{
+	"partner":    "read",
+	"contractor": "write",
+}
+
+/**
+ * ReadExternalUserDirectory reads all the external users files in the dirname
+ * directory (like ReadUserDirectory) and validates their role
+ */
+func ReadExternalUserDirectory(fs billy.Filesystem, dirname string) (map[string]*User, []error, []Warning) {
+	users, filenames, errors, warning := readUserDirectory(fs, dirname)
+	for username, user := range users {
+		if _, ok := ExternalUserRoles[user.Spec.Role]; user.Spec.Role != "" && !ok {
+			errors = append(errors, withFile(filenames[username], fmt.Errorf("invalid role: %s for external user %s", user.Spec.Role, username)))
+			delete(users, username)
+		}
+	}
 	return users, errors, warning
 }
 
@@ -129,6 +171,9 @@ func (u *User) Equals(a *User) bool {
 	if u.Spec.GithubID != a.Spec.GithubID {
 		return false
 	}
+	if u.Spec.Role != a.Spec.Role {
+		return false
+	}
 
 	return true
 }
@@ -55,6 +55,40 @@ spec:
 		assert.Equal(t, "github1", user1.Spec.GithubID)
 	})
 
+	t.Run("happy path: .yml extension is a warning", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "users/user1.yml", []byte(`
+apiVersion: v1
+kind: User
+name: user1
+spec:
+  githubID: github1
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, "github1", users["user1"].Spec.GithubID)
+	})
+
+	t.Run("not happy path: user defined in a .yaml and a .yml file", func(t *testing.T) {
+		fs := memfs.New()
+		for _, filename := range []string{"users/user1.yaml", "users/user1.yml"} {
+			err := utils.WriteFile(fs, filename, []byte(`
+apiVersion: v1
+kind: User
+name: user1
+spec:
+  githubID: github1
+`), 0644)
+			assert.Nil(t, err)
+		}
+		users, errs, _ := ReadUserDirectory(fs, "users")
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "User user1 defined in 2 places")
+		assert.Equal(t, 1, len(users))
+	})
+
 	t.Run("not happy path: no users directory", func(t *testing.T) {
 		// create a new user starting with "---"
 		fs := memfs.New()
@@ -117,3 +151,41 @@ func TestEqualUser(t *testing.T) {
 		assert.False(t, res)
 	})
 }
+
+func TestExternalUserRole(t *testing.T) {
+	t.Run("happy path: known role", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "external/partner1.yaml", []byte(`
+apiVersion: v1
+kind: User
+name: partner1
+spec:
+  githubID: github1
+  role: partner
+`), 0644)
+		assert.Nil(t, err)
+
+		users, errs, warns := ReadExternalUserDirectory(fs, "external")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, "partner", users["partner1"].Spec.Role)
+	})
+
+	t.Run("not happy path: unknown role", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "external/partner1.yaml", []byte(`
+apiVersion: v1
+kind: User
+name: partner1
+spec:
+  githubID: github1
+  role: admin
+`), 0644)
+		assert.Nil(t, err)
+
+		users, errs, _ := ReadExternalUserDirectory(fs, "external")
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "external/partner1.yaml", validationFile(errs[0]))
+		assert.Equal(t, 0, len(users))
+	})
+}
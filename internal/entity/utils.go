@@ -1,5 +1,21 @@
 package entity
 
+import (
+	"path/filepath"
+)
+
+/*
+ * isYamlFile returns true if the file has a .yaml (or .yml) extension
+ */
+func isYamlFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func ymlExtensionWarning(filename string) Warning {
+	return withFile(filename, NewCodedWarning("yml-extension", "file %s has a .yml extension, .yaml is preferred", filename))
+}
+
 /*
  * Compare 2 string arrays to see if they contains the same elements
  * Returns
@@ -40,3 +56,11 @@ func StringArrayEquivalent(a, b []string) (bool, []string, []string) {
 	}
 	return result, leftOnly, rightOnly
 }
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
@@ -168,6 +168,34 @@ func (g *GithubBatchExecutor) UpdateRepositoryUpdateBoolProperty(ctx context.Con
 	})
 }
 
+func (g *GithubBatchExecutor) UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryUpdateStringProperty{
+		client:        g.client,
+		dryrun:        dryrun,
+		reponame:      reponame,
+		propertyName:  propertyName,
+		propertyValue: propertyValue,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, reponame string, topics []string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryUpdateTopics{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		topics:   topics,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, reponame string, branch string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryUpdateDefaultBranch{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		branch:   branch,
+	})
+}
+
 func (g *GithubBatchExecutor) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	g.commands = append(g.commands, &GithubCommandUpdateRepositorySetExternalUser{
 		client:     g.client,
@@ -440,6 +468,40 @@ func (g *GithubCommandUpdateRepositoryUpdateBoolProperty) Apply(ctx context.Cont
 	g.client.UpdateRepositoryUpdateBoolProperty(ctx, g.dryrun, g.reponame, g.propertyName, g.propertyValue)
 }
 
+type GithubCommandUpdateRepositoryUpdateStringProperty struct {
+	client        engine.ReconciliatorExecutor
+	dryrun        bool
+	reponame      string
+	propertyName  string
+	propertyValue string
+}
+
+func (g *GithubCommandUpdateRepositoryUpdateStringProperty) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryUpdateStringProperty(ctx, g.dryrun, g.reponame, g.propertyName, g.propertyValue)
+}
+
+type GithubCommandUpdateRepositoryUpdateTopics struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	topics   []string
+}
+
+func (g *GithubCommandUpdateRepositoryUpdateTopics) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryUpdateTopics(ctx, g.dryrun, g.reponame, g.topics)
+}
+
+type GithubCommandUpdateRepositoryUpdateDefaultBranch struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	branch   string
+}
+
+func (g *GithubCommandUpdateRepositoryUpdateDefaultBranch) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryUpdateDefaultBranch(ctx, g.dryrun, g.reponame, g.branch)
+}
+
 type GithubCommandUpdateTeamAddMember struct {
 	client   engine.ReconciliatorExecutor
 	dryrun   bool
@@ -144,8 +144,8 @@ func (g *GoliacImpl) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool
 	}
 
 	unmanaged, err := g.applyToGithub(ctx, dryrun, config.Config.GithubAppOrganization, teamreponame, branch, config.Config.SyncUsersBeforeApply)
-	for _, warn := range warns {
-		logrus.Warn(warn)
+	if len(warns) != 0 {
+		logrus.Warn(entity.FormatValidationResults(nil, warns, false))
 	}
 	if err != nil {
 		return err, errs, warns, unmanaged
@@ -187,15 +187,13 @@ func (g *GoliacImpl) loadAndValidateGoliacOrganization(ctx context.Context, fs b
 		errs, warns = g.local.LoadAndValidateLocal(subfs)
 	}
 
-	for _, warn := range warns {
-		logrus.Debug(warn)
-	}
 	if len(errs) != 0 {
-		for _, err := range errs {
-			logrus.Error(err)
-		}
+		logrus.Error(entity.FormatValidationResults(errs, warns, false))
 		return fmt.Errorf("not able to load and validate the goliac organization: see logs"), errs, warns
 	}
+	if len(warns) != 0 {
+		logrus.Debug(entity.FormatValidationResults(nil, warns, false))
+	}
 
 	return nil, errs, warns
 }
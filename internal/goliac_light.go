@@ -5,8 +5,8 @@ import (
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
 	"github.com/go-git/go-billy/v5/osfs"
-	"github.com/sirupsen/logrus"
 )
 
 /*
@@ -35,14 +35,11 @@ func (g *GoliacLightImpl) Validate(path string) error {
 	fs := osfs.New(path)
 	errs, warns := g.local.LoadAndValidateLocal(fs)
 
-	for _, warn := range warns {
-		logrus.Warn(warn)
+	if len(errs) != 0 || len(warns) != 0 {
+		fmt.Print(entity.FormatValidationResults(errs, warns, false))
 	}
 	if len(errs) != 0 {
-		for _, err := range errs {
-			logrus.Error(err)
-		}
-		return fmt.Errorf("not able to validate the goliac organization: see logs")
+		return fmt.Errorf("not able to validate the goliac organization: see the validation report")
 	}
 
 	return nil
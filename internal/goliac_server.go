@@ -571,6 +571,8 @@ func (g *GoliacServerImpl) GetStatus(app.GetStatusParams) middleware.Responder {
 	if g.lastSyncError != nil {
 		s.LastSyncError = g.lastSyncError.Error()
 	}
+	// the UI lists the errors and warnings one by one: they are kept as is
+	// (FormatValidationResults is meant for the text outputs)
 	if g.detailedErrors != nil {
 		for _, err := range g.detailedErrors {
 			s.DetailedErrors = append(s.DetailedErrors, err.Error())
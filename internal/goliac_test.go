@@ -134,6 +134,8 @@ spec:
     - ruletype: pull_request
       parameters:
         requiredApprovingReviewCount: 1
+    - ruletype: deletion
+    - ruletype: non_fast_forward
 `), 0644)
 
 	// create .github/CODEOWNERS
@@ -522,6 +524,8 @@ func (e *GoliacRemoteExecutorMock) RuleSets(ctx context.Context) map[string]*eng
 				"pull_request": {
 					RequiredApprovingReviewCount: 1,
 				},
+				"deletion":         {},
+				"non_fast_forward": {},
 			},
 			Repositories: []string{"repo1", "repo2", "src"},
 		},
@@ -635,6 +639,18 @@ func (e *GoliacRemoteExecutorMock) DeleteRepository(ctx context.Context, dryrun
 	fmt.Println("*** DeleteRepository", reponame)
 	e.nbChanges++
 }
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateStringProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	fmt.Println("*** UpdateRepositoryUpdateStringProperty", reponame, propertyName, propertyValue)
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateTopics(ctx context.Context, dryrun bool, reponame string, topics []string) {
+	fmt.Println("*** UpdateRepositoryUpdateTopics", reponame, topics)
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateDefaultBranch(ctx context.Context, dryrun bool, reponame string, branch string) {
+	fmt.Println("*** UpdateRepositoryUpdateDefaultBranch", reponame, branch)
+	e.nbChanges++
+}
 func (e *GoliacRemoteExecutorMock) RenameRepository(ctx context.Context, dryrun bool, reponame string, newname string) {
 	fmt.Println("*** RenameRepository", reponame, newname)
 	e.nbChanges++
@@ -283,10 +283,7 @@ func (s *Scaffold) generateTeams(ctx context.Context, fs billy.Filesystem, teams
 							}
 							lRuleset.Enforcement = rRuleset.Enforcement
 							for appname, mode := range rRuleset.BypassApps {
-								lRuleset.BypassApps = append(lRuleset.BypassApps, struct {
-									AppName string
-									Mode    string
-								}{
+								lRuleset.BypassApps = append(lRuleset.BypassApps, entity.RuleSetBypassApp{
 									AppName: appname,
 									Mode:    mode,
 								})
@@ -294,10 +291,7 @@ func (s *Scaffold) generateTeams(ctx context.Context, fs billy.Filesystem, teams
 							lRuleset.Conditions.Include = rRuleset.OnInclude
 							lRuleset.Conditions.Exclude = rRuleset.OnExclude
 							for rulename, rulespec := range rRuleset.Rules {
-								lRuleset.Rules = append(lRuleset.Rules, struct {
-									Ruletype   string
-									Parameters entity.RuleSetParameters `yaml:"parameters,omitempty"`
-								}{
+								lRuleset.Rules = append(lRuleset.Rules, entity.RuleSetRule{
 									Ruletype:   rulename,
 									Parameters: rulespec,
 								})
@@ -465,6 +459,8 @@ spec:
     - ruletype: pull_request
       parameters:
         requiredApprovingReviewCount: 1
+    - ruletype: deletion
+    - ruletype: non_fast_forward
 `, s.githubappname)
 	if err := writeFile(path.Join(rulesetspath, "default.yaml"), []byte(ruleset), fs); err != nil {
 		return err
@@ -555,7 +551,7 @@ apiVersion: v1
 kind: Repository
 name: awesome-repository
 spec:
-  public: true
+  visibility: public
   writers:
   - anotherteamA
   - anotherteamB